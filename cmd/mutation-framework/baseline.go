@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Name of the pristine copy of the project inside the mutant folder
+const baselineFolder = "baseline"
+
+// Timeouts derived from the baseline are never shorter than this
+const minimumMutantTimeout = 5 * time.Second
+
+// How the unmutated project did
+type baselineResult struct {
+	duration   time.Duration
+	flakyTests map[string]struct{}
+}
+
+// Runs build and test on a pristine copy of the project. If the tests don't pass
+// without mutations, every mutant would look killed, so this returns an error.
+// With repeat set, the tests are run several times to find flaky tests.
+// When a timeout factor is configured, the mutant timeout is derived from the duration.
+func runBaseline(config *MutationConfig, files map[string]string) (*baselineResult, error) {
+	log.Info("Running tests against the unmutated project.")
+
+	baselineDir := appendFolder(getAbsoluteMutationFolderPath(config), baselineFolder)
+
+	err := FS.RemoveAll(baselineDir)
+	if err != nil {
+		return nil, err
+	}
+	defer FS.RemoveAll(baselineDir)
+
+	err = copyRecursive(true, filepath.Clean(config.ProjectRoot), baselineDir, config.Mutate.MutantFolder,
+		config.Mutate.getWorkspaceStrategy())
+	if err != nil {
+		return nil, err
+	}
+
+	workspace, err := newWorkspace(config, baselineDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []*commandResult
+	for i := 0; i < config.Test.getRepeat(); i++ {
+		log.WithField("run", i+1).Debug("Running baseline.")
+
+		run, err := runBaselineOnce(config, files, workspace)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	flaky, err := analyzeBaselineRuns(runs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &baselineResult{flakyTests: flaky}
+	for _, run := range runs {
+		// be generous with the timeout and go with the slowest run
+		if run.duration > result.duration {
+			result.duration = run.duration
+		}
+	}
+
+	flakyTests = flaky
+	if len(flaky) > 0 {
+		log.WithField("tests", getTestKey(testNames(flaky))).
+			Info("Flaky tests will not be counted as killing mutants.")
+	}
+
+	log.WithField("duration", result.duration).Info("Tests pass without mutations.")
+
+	config.Test.mutantTimeout = deriveMutantTimeout(config.Test.TimeoutFactor, result.duration)
+	if config.Test.mutantTimeout != 0 {
+		log.WithField("timeout", config.Test.mutantTimeout).Info("Derived timeout for mutants from the baseline.")
+	}
+
+	return result, nil
+}
+
+// Builds and tests the pristine copy once
+// The error is only set if the tests could not be run at all
+func runBaselineOnce(config *MutationConfig, files map[string]string, workspace *mutantWorkspace) (*commandResult, error) {
+	defer runCleanUpCommand(config, workspace)
+
+	if outcome, ok := runBuildCommand(config.Test.Commands.Build, workspace); !ok {
+		return nil, fmt.Errorf("baseline build failed on the unmutated project (%s)", outcome)
+	}
+
+	if config.Test.usesCluster() {
+		proxy, err := startFaultProxy(config, workspace, false)
+		if err != nil {
+			return nil, err
+		}
+		defer stopFaultProxy(proxy)
+
+		cluster, err := launchBaselineCluster(config, workspace)
+		if err != nil {
+			return nil, err
+		}
+		defer cluster.stop()
+	}
+
+	var relativeFiles []string
+	for file := range files {
+		relativeFiles = append(relativeFiles, file)
+	}
+
+	testCommand := defaultTestCommand(workspace, relativeFiles...)
+	if config.Test.Commands.Test != "" {
+		testCommand = customTestCommand(config.Test.Commands.Test, workspace)
+	}
+
+	result, err := workspace.run(testCommand)
+	if err != nil {
+		return nil, fmt.Errorf("could not run the tests on the unmutated project: %v", err)
+	}
+
+	log.Debug("Baseline test output: ", string(result.output))
+
+	return result, nil
+}
+
+func testNames(tests map[string]struct{}) []string {
+	names := make([]string, 0, len(tests))
+	for test := range tests {
+		names = append(names, test)
+	}
+
+	return names
+}
+
+// A multiple of the baseline duration, or 0 if no factor is configured
+func deriveMutantTimeout(factor float64, baseline time.Duration) time.Duration {
+	if factor <= 0 {
+		return 0
+	}
+
+	timeout := time.Duration(factor * float64(baseline))
+	if timeout < minimumMutantTimeout {
+		return minimumMutantTimeout
+	}
+
+	return timeout
+}
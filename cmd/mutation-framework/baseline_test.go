@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveMutantTimeout(t *testing.T) {
+	assert.Equal(t, time.Duration(0), deriveMutantTimeout(0, 5*time.Second))
+	assert.Equal(t, 15*time.Second, deriveMutantTimeout(3, 5*time.Second))
+	assert.Equal(t, 7500*time.Millisecond, deriveMutantTimeout(1.5, 5*time.Second))
+	assert.Equal(t, minimumMutantTimeout, deriveMutantTimeout(2, 10*time.Millisecond))
+}
+
+func TestGetMutantTimeout(t *testing.T) {
+	test := Test{}
+	assert.Equal(t, DefaultTimeout*time.Second, test.getMutantTimeout())
+
+	test.Timeout = 30
+	assert.Equal(t, 30*time.Second, test.getMutantTimeout())
+
+	test.mutantTimeout = 4 * time.Second
+	assert.Equal(t, 4*time.Second, test.getMutantTimeout())
+	assert.Equal(t, 30*time.Second, test.getTimeout())
+}
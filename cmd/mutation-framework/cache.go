@@ -0,0 +1,272 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+const resultCacheVersion = 1
+
+// Verdicts of earlier runs, so that mutants whose verdict can't have changed are not executed again
+// A verdict is keyed by the mutated file and everything else the tests see, see resultCache.key
+type resultCache struct {
+	path string
+	// hash of every input file of the project, by path relative to the project root
+	inputs map[string]string
+	// the commands, timeout settings, equivalence detection, cluster, faults, reruns and flaky tests
+	// the tests are run with, see getCacheSettings
+	settings string
+
+	lock    sync.Mutex
+	entries map[string]cacheEntry
+	// keys of the entries this run looked up or stored, the others are dropped when saving
+	used   map[string]struct{}
+	hits   int
+	stored int
+}
+
+type resultCacheFile struct {
+	Version int                   `json:"version"`
+	Entries map[string]cacheEntry `json:"entries"`
+}
+
+type cacheEntry struct {
+	Outcome         string   `json:"outcome"`
+	FailedTests     []string `json:"failed_tests,omitempty"`
+	DurationSeconds float64  `json:"duration_seconds"`
+	// outcome by composition, see Test.Compositions
+	Compositions map[string]string `json:"compositions,omitempty"`
+}
+
+// Loads the cache of the config, nil if there is none
+// A cache file that can't be read is started over instead of failing the run.
+func newResultCache(config *MutationConfig) *resultCache {
+	if config.Test.Cache == "" {
+		return nil
+	}
+
+	inputs, err := hashProjectInputs(config)
+	if err != nil {
+		log.WithField("error", err).Warn("Could not hash the project, not using the result cache.")
+		return nil
+	}
+
+	cache := &resultCache{
+		path:     config.Test.Cache,
+		inputs:   inputs,
+		settings: getCacheSettings(config),
+		entries:  make(map[string]cacheEntry),
+		used:     make(map[string]struct{}),
+	}
+
+	data, err := afero.ReadFile(FS, cache.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.WithFields(log.Fields{"path": cache.path, "error": err}).Warn("Could not read result cache, starting over.")
+		}
+		return cache
+	}
+
+	var file resultCacheFile
+	if err := json.Unmarshal(data, &file); err != nil || file.Version != resultCacheVersion {
+		log.WithField("path", cache.path).Warn("Result cache is invalid or outdated, starting over.")
+		return cache
+	}
+	if file.Entries != nil {
+		cache.entries = file.Entries
+	}
+
+	log.WithFields(log.Fields{"path": cache.path, "entries": len(cache.entries)}).Info("Loaded result cache.")
+	return cache
+}
+
+// Everything besides the project which the verdicts depend on. The flaky tests are known
+// once the baseline ran, since failures of flaky tests don't kill mutants.
+func getCacheSettings(config *MutationConfig) string {
+	flaky := testNames(flakyTests)
+	sort.Strings(flaky)
+
+	return fmt.Sprintf("%q %q %d %f %t %q %d %v %q %v %d %t %q", config.Test.Commands.Build, config.Test.Commands.Test,
+		config.Test.Timeout, config.Test.TimeoutFactor, config.Test.DetectEquivalent,
+		config.Test.Commands.Launch, config.Test.Nodes, config.Test.getCompositions(), config.Test.getNodePackage(),
+		config.Test.Faults, config.Test.getRepeat(), config.Test.RerunSurvivors, flaky)
+}
+
+// Hashes the Go files, go.mod and go.sum of the project, leaving out the mutants and hidden folders
+func hashProjectInputs(config *MutationConfig) (map[string]string, error) {
+	inputs := make(map[string]string)
+	mutantFolder := filepath.Clean(getAbsoluteMutationFolderPath(config))
+
+	err := afero.Walk(FS, config.ProjectRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if path != config.ProjectRoot && (strings.HasPrefix(info.Name(), ".") || filepath.Clean(path) == mutantFolder) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		name := info.Name()
+		if !strings.HasSuffix(name, ".go") && name != "go.mod" && name != "go.sum" {
+			return nil
+		}
+
+		data, err := afero.ReadFile(FS, path)
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(config.ProjectRoot, path)
+		if err != nil {
+			return err
+		}
+		inputs[filepath.ToSlash(relative)] = fmt.Sprintf("%x", sha256.Sum256(data))
+
+		return nil
+	})
+
+	return inputs, err
+}
+
+// The key of a mutant is the hash of its mutated file, of every other input of the project
+// and of the test settings. The original of the mutated file is replaced by the mutant,
+// so it is left out, which keeps the verdicts of other files when one file changes.
+func (cache *resultCache) key(mutant MutantInfo) (string, error) {
+	mutated, err := afero.ReadFile(FS, mutant.mutationFileAbsPath)
+	if err != nil {
+		return "", err
+	}
+
+	original := filepath.ToSlash(filepath.Clean(mutant.originalFileRelativePath))
+	var files []string
+	for file := range cache.inputs {
+		if file != original {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%x\n%s\n", original, sha256.Sum256(mutated), cache.settings)
+	for _, file := range files {
+		fmt.Fprintf(h, "%s %s\n", file, cache.inputs[file])
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// The cached verdict of the mutant, if there is one
+func (cache *resultCache) lookup(mutant MutantInfo) (*testRun, bool) {
+	if cache == nil {
+		return nil, false
+	}
+
+	key, err := cache.key(mutant)
+	if err != nil {
+		return nil, false
+	}
+
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	entry, ok := cache.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	outcome, ok := parseOutcome(entry.Outcome)
+	if !ok {
+		return nil, false
+	}
+	compositions, ok := parseCompositions(entry.Compositions)
+	if !ok {
+		return nil, false
+	}
+
+	cache.hits++
+	cache.used[key] = struct{}{}
+	return &testRun{
+		outcome:      outcome,
+		failedTests:  entry.FailedTests,
+		duration:     time.Duration(entry.DurationSeconds * float64(time.Second)),
+		compositions: compositions,
+	}, true
+}
+
+// Remembers the verdict of the mutant. Timeouts depend on the load of the machine,
+// verdicts the runs disagreed on may change and errors say nothing about the mutant, so they are not kept.
+func (cache *resultCache) store(result *mutantResult) {
+	if cache == nil || result.outcome == outcomeTimedOut || result.confidence() < 1 || result.hasErrors() {
+		return
+	}
+
+	key, err := cache.key(result.mutant)
+	if err != nil {
+		log.WithFields(log.Fields{"mutant": result.mutant.mutationFileAbsPath, "error": err}).
+			Debug("Could not cache result.")
+		return
+	}
+
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	cache.entries[key] = cacheEntry{
+		Outcome:         result.outcome.String(),
+		FailedTests:     result.failedTests,
+		DurationSeconds: result.duration.Seconds(),
+		Compositions:    formatCompositions(result.compositions),
+	}
+	cache.used[key] = struct{}{}
+	cache.stored++
+}
+
+// Writes the entries this run used back to the cache file. The others belong to mutants
+// that are gone or whose inputs changed, so they would only make the file grow.
+func (cache *resultCache) save() {
+	if cache == nil {
+		return
+	}
+
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	entries := make(map[string]cacheEntry, len(cache.used))
+	for key := range cache.used {
+		entries[key] = cache.entries[key]
+	}
+
+	data, err := json.MarshalIndent(resultCacheFile{resultCacheVersion, entries}, "", "  ")
+	if err == nil {
+		err = afero.WriteFile(FS, cache.path, data, 0644)
+	}
+	if err != nil {
+		log.WithField("path", cache.path).Error(fmt.Sprintf("Could not write result cache: %v", err))
+		return
+	}
+
+	log.WithFields(log.Fields{"path": cache.path, "hits": cache.hits, "stored": cache.stored,
+		"pruned": len(cache.entries) - len(entries)}).Info("Wrote result cache.")
+}
+
+func parseOutcome(name string) (mutantOutcome, bool) {
+	for _, outcome := range []mutantOutcome{outcomeKilled, outcomeSurvived, outcomeTimedOut, outcomeCrashed, outcomeNotCompiling, outcomeEquivalent, outcomeError} {
+		if outcome.String() == name {
+			return outcome, true
+		}
+	}
+
+	return 0, false
+}
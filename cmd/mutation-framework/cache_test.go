@@ -0,0 +1,201 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func getCacheTestConfig() *MutationConfig {
+	config := &MutationConfig{ProjectRoot: "/project"}
+	config.Mutate.MutantFolder = "mutants/"
+	config.Test.Cache = "/cache.json"
+
+	afero.WriteFile(FS, "/project/go.mod", []byte("module calc\n"), 0644)
+	afero.WriteFile(FS, "/project/calc.go", []byte("package calc\n"), 0644)
+	afero.WriteFile(FS, "/project/calc_test.go", []byte("package calc\n"), 0644)
+	afero.WriteFile(FS, "/project/other.go", []byte("package calc\n"), 0644)
+	afero.WriteFile(FS, "/project/mutants/calc.go/1/calc.go", []byte("package calc // mutated\n"), 0644)
+
+	return config
+}
+
+func getCacheTestMutant() MutantInfo {
+	return MutantInfo{
+		originalFileRelativePath: "calc.go",
+		mutantDirPathAbsPath:     "/project/mutants/calc.go/1",
+		mutationFileAbsPath:      "/project/mutants/calc.go/1/calc.go",
+		operator:                 "branch/if",
+	}
+}
+
+func TestHashProjectInputs(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := getCacheTestConfig()
+	afero.WriteFile(FS, "/project/.git/hooks.go", []byte("package hooks\n"), 0644)
+	afero.WriteFile(FS, "/project/README.md", []byte("calc\n"), 0644)
+
+	inputs, err := hashProjectInputs(config)
+	assert.Nil(t, err)
+
+	var files []string
+	for file := range inputs {
+		files = append(files, file)
+	}
+	assert.ElementsMatch(t, []string{"go.mod", "calc.go", "calc_test.go", "other.go"}, files)
+}
+
+func TestResultCacheRoundTrip(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := getCacheTestConfig()
+	mutant := getCacheTestMutant()
+
+	cache := newResultCache(config)
+	_, ok := cache.lookup(mutant)
+	assert.False(t, ok)
+
+	cache.store(newMutantResult(mutant, &testRun{outcome: outcomeKilled, failedTests: []string{"TestAbs"}, duration: 2 * time.Second}))
+	cache.save()
+
+	cache = newResultCache(config)
+	run, ok := cache.lookup(mutant)
+	assert.True(t, ok)
+	assert.Equal(t, outcomeKilled, run.outcome)
+	assert.Equal(t, []string{"TestAbs"}, run.failedTests)
+	assert.Equal(t, 2*time.Second, run.duration)
+}
+
+func TestResultCacheInvalidation(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := getCacheTestConfig()
+	mutant := getCacheTestMutant()
+
+	cache := newResultCache(config)
+	cache.store(newMutantResult(mutant, &testRun{outcome: outcomeSurvived}))
+	cache.save()
+
+	// the original of the mutated file is replaced by the mutant, so it doesn't matter
+	afero.WriteFile(FS, "/project/calc.go", []byte("package calc\n\nfunc Abs() {}\n"), 0644)
+	_, ok := newResultCache(config).lookup(mutant)
+	assert.True(t, ok)
+
+	afero.WriteFile(FS, "/project/calc_test.go", []byte("package calc\n\nfunc TestAbs() {}\n"), 0644)
+	_, ok = newResultCache(config).lookup(mutant)
+	assert.False(t, ok)
+
+	afero.WriteFile(FS, "/project/calc_test.go", []byte("package calc\n"), 0644)
+	config.Test.Commands.Test = "make test"
+	_, ok = newResultCache(config).lookup(mutant)
+	assert.False(t, ok)
+	config.Test.Commands.Test = ""
+
+	// reruns and flaky tests change the verdicts as well
+	config.Test.Repeat = 3
+	_, ok = newResultCache(config).lookup(mutant)
+	assert.False(t, ok)
+	config.Test.Repeat = 0
+
+	config.Test.RerunSurvivors = true
+	_, ok = newResultCache(config).lookup(mutant)
+	assert.False(t, ok)
+	config.Test.RerunSurvivors = false
+
+	flakyTests = map[string]struct{}{"calc.TestAbs": {}}
+	defer func() { flakyTests = make(map[string]struct{}) }()
+	_, ok = newResultCache(config).lookup(mutant)
+	assert.False(t, ok)
+}
+
+func TestResultCacheKeepsResumedMutants(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := getCacheTestConfig()
+	mutant := getCacheTestMutant()
+
+	// the interrupted run cached the verdict and recorded it in the journal
+	cache := newResultCache(config)
+	result := newMutantResult(mutant, &testRun{outcome: outcomeKilled, failedTests: []string{"TestAbs"}})
+	cache.store(result)
+	cache.save()
+	journal, err := openRunJournal(config)
+	assert.Nil(t, err)
+	journal.record(config, result)
+
+	config.Test.Resume = true
+	journal, err = openRunJournal(config)
+	assert.Nil(t, err)
+	cache = newResultCache(config)
+	resumed := executeForMutant(config, mutant, &mutationStats{}, cache, journal, nil)
+	assert.Equal(t, outcomeKilled, resumed.outcome)
+	cache.save()
+
+	_, ok := newResultCache(config).lookup(mutant)
+	assert.True(t, ok)
+}
+
+func TestResultCachePrunesUnusedEntries(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := getCacheTestConfig()
+	mutant := getCacheTestMutant()
+	afero.WriteFile(FS, "/project/mutants/calc.go/2/calc.go", []byte("package calc // mutated again\n"), 0644)
+	other := MutantInfo{originalFileRelativePath: "calc.go", mutantDirPathAbsPath: "/project/mutants/calc.go/2",
+		mutationFileAbsPath: "/project/mutants/calc.go/2/calc.go", operator: "branch/if"}
+
+	cache := newResultCache(config)
+	cache.store(newMutantResult(mutant, &testRun{outcome: outcomeKilled}))
+	cache.store(newMutantResult(other, &testRun{outcome: outcomeKilled}))
+	cache.save()
+
+	// only the first mutant is left in the next run
+	cache = newResultCache(config)
+	_, ok := cache.lookup(mutant)
+	assert.True(t, ok)
+	cache.save()
+
+	cache = newResultCache(config)
+	assert.Len(t, cache.entries, 1)
+	_, ok = cache.lookup(mutant)
+	assert.True(t, ok)
+	_, ok = cache.lookup(other)
+	assert.False(t, ok)
+}
+
+func TestResultCacheSkipsUnsettledVerdicts(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := getCacheTestConfig()
+	mutant := getCacheTestMutant()
+	cache := newResultCache(config)
+
+	cache.store(newMutantResult(mutant, &testRun{outcome: outcomeTimedOut}))
+	_, ok := cache.lookup(mutant)
+	assert.False(t, ok)
+
+	cache.store(newMutantResult(mutant, &testRun{outcome: outcomeKilled,
+		compositions: map[string]mutantOutcome{"leader": outcomeKilled, "follower": outcomeError}}))
+	_, ok = cache.lookup(mutant)
+	assert.False(t, ok)
+
+	result := newMutantResult(mutant, &testRun{outcome: outcomeSurvived})
+	result.runs = append(result.runs, &testRun{outcome: outcomeKilled})
+	cache.store(result)
+	_, ok = cache.lookup(mutant)
+	assert.False(t, ok)
+
+	var disabled *resultCache
+	disabled.store(result)
+	_, ok = disabled.lookup(mutant)
+	assert.False(t, ok)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// Name of the folder inside the mutant folder which holds the original node binary
+// and the logs of the nodes of the baseline
+const clusterFolder = "cluster"
+
+// Name of the node binary, in the cluster folder for the original and in the mutant for mutants
+const nodeBinary = "mutation-node"
+
+// Nodes of a distributed system which the test command of a mutant runs against,
+// some of them running the mutant and the others the original
+type nodeCluster struct {
+	nodes []*clusterNode
+}
+
+type clusterNode struct {
+	index   int
+	mutated bool
+	cmd     *exec.Cmd
+	log     afero.File
+}
+
+// Whether the tests run against a cluster which is launched for each mutant
+func (test *Test) usesCluster() bool {
+	return test.Commands.Launch != ""
+}
+
+// Number of nodes which run the mutant, 1 unless configured
+func (test *Test) getComposition() int {
+	if test.Composition == 0 {
+		return 1
+	}
+
+	return test.Composition
+}
+
+// The main package of the node binary, relative to the project root
+func (test *Test) getNodePackage() string {
+	if test.NodePackage == "" {
+		return "."
+	}
+
+	return test.NodePackage
+}
+
+func getClusterDir(config *MutationConfig) string {
+	return appendFolder(getAbsoluteMutationFolderPath(config), clusterFolder)
+}
+
+func getOriginalBinaryPath(config *MutationConfig) string {
+	return appendFolder(getClusterDir(config), nodeBinary)
+}
+
+// Builds the node binary in the workspace, for mutants with their overlay
+func buildNodeBinary(config *MutationConfig, workspace *mutantWorkspace, output string) (mutantOutcome, bool) {
+	args := []string{"build", "-o", output}
+	if workspace.overlay != "" {
+		args = append(args, "-overlay", workspace.overlay)
+	}
+	args = append(args, "."+string(os.PathSeparator)+filepath.Clean(config.Test.getNodePackage()))
+
+	log.WithField("binary", output).Debug("Building node binary.")
+	result, err := workspace.run(workspace.command("go", args...))
+	if err != nil {
+		log.WithField("binary", output).Error(err)
+		return outcomeError, false
+	}
+
+	if result.timedOut {
+		return outcomeTimedOut, false
+	} else if result.exitCode != 0 || result.signaled {
+		log.WithFields(log.Fields{"exit_code": result.exitCode, "output": string(result.output)}).
+			Info("Node binary does not build.")
+		return outcomeNotCompiling, false
+	}
+
+	return outcomeKilled, true
+}
+
+// Launches the nodes of the cluster with the launch command, the mutated ones with the mutant
+// binary and the others with the original. The output of each node goes to node-<index>.log in
+// the log folder. The test command learns about the cluster through the workspace environment.
+func launchCluster(config *MutationConfig, workspace *mutantWorkspace, logDir string, mutantBinary string,
+	mutated []int) (*nodeCluster, error) {
+	launch := strings.Split(config.Test.Commands.Launch, " ")
+	cluster := &nodeCluster{}
+
+	isMutated := make(map[int]bool)
+	for _, i := range mutated {
+		isMutated[i] = true
+	}
+
+	var mutatedNodes []string
+	for i := 0; i < config.Test.Nodes; i++ {
+		node := &clusterNode{index: i, mutated: isMutated[i]}
+
+		binary := getOriginalBinaryPath(config)
+		if node.mutated {
+			binary = mutantBinary
+			mutatedNodes = append(mutatedNodes, fmt.Sprintf("%d", i))
+		}
+
+		var err error
+		node.log, err = FS.Create(appendFolder(logDir, fmt.Sprintf("node-%d.log", i)))
+		if err != nil {
+			cluster.stop()
+			return nil, err
+		}
+
+		node.cmd = workspace.command(launch[0], launch[1:]...)
+		node.cmd.Env = setEnv(node.cmd.Env, "MUTATE_NODE", fmt.Sprintf("%d", i))
+		node.cmd.Env = setEnv(node.cmd.Env, "MUTATE_NODES", fmt.Sprintf("%d", config.Test.Nodes))
+		node.cmd.Env = setEnv(node.cmd.Env, "MUTATE_BINARY", binary)
+		node.cmd.Env = setEnv(node.cmd.Env, "MUTATE_NODE_MUTATED", fmt.Sprintf("%t", node.mutated))
+		node.cmd.Stdout = node.log
+		node.cmd.Stderr = node.log
+		// like commands, nodes get a process group of their own so that stopping them stops their children
+		node.cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+		log.WithFields(log.Fields{"node": i, "mutated": node.mutated, "binary": binary}).Debug("Launching node.")
+		err = node.cmd.Start()
+		if err != nil {
+			node.log.Close()
+			cluster.stop()
+			return nil, fmt.Errorf("could not launch node %d: %v", i, err)
+		}
+
+		cluster.nodes = append(cluster.nodes, node)
+	}
+
+	workspace.env = setEnv(workspace.env, "MUTATE_NODES", fmt.Sprintf("%d", config.Test.Nodes))
+	workspace.env = setEnv(workspace.env, "MUTATE_MUTATED_NODES", strings.Join(mutatedNodes, ","))
+
+	return cluster, nil
+}
+
+// Kills every node of the cluster along with everything it started
+func (cluster *nodeCluster) stop() {
+	for _, node := range cluster.nodes {
+		killProcessGroup(node.cmd)
+		err := node.cmd.Wait()
+		log.WithFields(log.Fields{"node": node.index, "exit": err}).Debug("Stopped node.")
+		node.log.Close()
+	}
+	cluster.nodes = nil
+}
+
+// Builds the original binary and launches a cluster of original nodes for the baseline
+func launchBaselineCluster(config *MutationConfig, workspace *mutantWorkspace) (*nodeCluster, error) {
+	err := FS.MkdirAll(getClusterDir(config), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	if outcome, ok := buildNodeBinary(config, workspace, getOriginalBinaryPath(config)); !ok {
+		return nil, fmt.Errorf("the node binary of the unmutated project failed to build (%s)", outcome)
+	}
+
+	return launchCluster(config, workspace, getClusterDir(config), "", nil)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterConfig(t *testing.T) {
+	config, err := parseAndValidateConfig([]byte(`{"project_root":"home","test":{"nodes":5,"commands":{"launch":"./start.sh"}}}`))
+	assert.Nil(t, err)
+	assert.True(t, config.Test.usesCluster())
+	assert.Equal(t, 1, config.Test.getComposition())
+	assert.Equal(t, ".", config.Test.getNodePackage())
+
+	for _, invalid := range []string{
+		`{"project_root":"home","test":{"commands":{"launch":"./start.sh"}}}`,
+		`{"project_root":"home","test":{"nodes":3,"composition":4,"commands":{"launch":"./start.sh"}}}`,
+		`{"project_root":"home","test":{"nodes":3,"workers":2,"commands":{"launch":"./start.sh"}}}`,
+	} {
+		_, err = parseAndValidateConfig([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+
+	config, err = parseAndValidateConfig([]byte(`{"project_root":"home","test":{"composition":2}}`))
+	assert.Nil(t, err)
+	assert.False(t, config.Test.usesCluster())
+}
+
+func TestLaunchCluster(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mutation-testing")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// every node writes its environment and waits to be stopped
+	script := filepath.Join(dir, "launch.sh")
+	assert.Nil(t, ioutil.WriteFile(script, []byte(
+		"echo $MUTATE_NODE $MUTATE_NODES $MUTATE_BINARY $MUTATE_NODE_MUTATED > node-$MUTATE_NODE.env\nexec sleep 60\n"), 0755))
+
+	config := &MutationConfig{ProjectRoot: dir}
+	config.Mutate.MutantFolder = "mutants/"
+	config.Test.Nodes = 3
+	config.Test.Commands.Launch = "sh " + script
+
+	workspace, err := newProjectWorkspace(config)
+	assert.Nil(t, err)
+	cluster, err := launchCluster(config, workspace, dir, "/mutant/mutation-node", []int{0, 1})
+	assert.Nil(t, err)
+	assert.Len(t, cluster.nodes, 3)
+	assert.Contains(t, workspace.env, "MUTATE_NODES=3")
+	assert.Contains(t, workspace.env, "MUTATE_MUTATED_NODES=0,1")
+
+	expected := []string{
+		"0 3 /mutant/mutation-node true",
+		"1 3 /mutant/mutation-node true",
+		"2 3 " + getOriginalBinaryPath(config) + " false",
+	}
+	for i, line := range expected {
+		var data []byte
+		for try := 0; try < 50; try++ {
+			data, err = ioutil.ReadFile(filepath.Join(dir, fmt.Sprintf("node-%d.env", i)))
+			if err == nil && len(data) > 0 {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		assert.Equal(t, line, strings.TrimSpace(string(data)))
+	}
+
+	nodes := cluster.nodes
+	cluster.stop()
+	for _, node := range nodes {
+		assert.NotNil(t, node.cmd.ProcessState)
+		assert.False(t, node.cmd.ProcessState.Success())
+	}
+	assert.Empty(t, cluster.nodes)
+}
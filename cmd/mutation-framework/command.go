@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// What happened when a build, test or clean up command was run
+type commandResult struct {
+	output   []byte
+	exitCode int
+	signaled bool
+	timedOut bool
+	duration time.Duration
+}
+
+// Runs the command in a process group of its own and kills the whole group
+// once the timeout is up, so that hanging servers spawned by tests die with it.
+// A timeout of 0 means the command may run forever.
+// The error is only set if the command could not be run at all.
+func runCommand(cmd *exec.Cmd, timeout time.Duration) (*commandResult, error) {
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	result := &commandResult{}
+	start := time.Now()
+
+	err := cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	select {
+	case err = <-done:
+	case <-deadline:
+		log.WithFields(log.Fields{"command": cmd.Args, "timeout": timeout}).Info("Command timed out, killing it.")
+		result.timedOut = true
+		killProcessGroup(cmd)
+		err = <-done
+	}
+
+	// don't leave anything behind that the command started in the background
+	killProcessGroup(cmd)
+
+	result.duration = time.Since(start)
+	result.output = output.Bytes()
+
+	if err == nil {
+		return result, nil
+	}
+
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return nil, err
+	}
+
+	status := exitErr.Sys().(syscall.WaitStatus)
+	if status.Signaled() {
+		result.signaled = true
+		result.exitCode = -1
+	} else {
+		result.exitCode = status.ExitStatus()
+	}
+
+	return result, nil
+}
+
+func killProcessGroup(cmd *exec.Cmd) {
+	// a negative pid addresses the process group
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// Converts the timeout from the config (in seconds) for runCommand
+func (test *Test) getTimeout() time.Duration {
+	if test.Timeout == 0 {
+		return DefaultTimeout * time.Second
+	}
+
+	return time.Duration(test.Timeout) * time.Second
+}
+
+// The timeout for the commands of a mutant, which is derived from
+// the baseline if a timeout factor is configured
+func (test *Test) getMutantTimeout() time.Duration {
+	if test.mutantTimeout != 0 {
+		return test.mutantTimeout
+	}
+
+	return test.getTimeout()
+}
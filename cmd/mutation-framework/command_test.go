@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCommand(t *testing.T) {
+	result, err := runCommand(exec.Command("sh", "-c", "echo hello; exit 3"), time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, result.exitCode)
+	assert.False(t, result.timedOut)
+	assert.Equal(t, "hello\n", string(result.output))
+
+	result, err = runCommand(exec.Command("sh", "-c", "kill -9 $$"), time.Second)
+	assert.Nil(t, err)
+	assert.True(t, result.signaled)
+
+	_, err = runCommand(exec.Command("this-command-does-not-exist"), time.Second)
+	assert.NotNil(t, err)
+}
+
+func TestRunCommandKillsProcessGroupOnTimeout(t *testing.T) {
+	start := time.Now()
+
+	// the background sleep keeps the output open, so this only returns if the whole group is killed
+	result, err := runCommand(exec.Command("sh", "-c", "sleep 30 & sleep 30"), 200*time.Millisecond)
+	assert.Nil(t, err)
+	assert.True(t, result.timedOut)
+	assert.True(t, time.Since(start) < 10*time.Second)
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// The difference between two runs, e.g. of the main branch and of a pull request
+// Mutants are matched by their stable key, see setStableKeys
+type reportComparison struct {
+	// survive in the new run, but were detected or did not compile in the old one
+	newlySurvived []reportMutant
+	// survive in the new run and did not exist in the old one
+	newSurvivors []reportMutant
+	// survived the old run and are detected in the new one
+	newlyKilled []reportMutant
+	// mutants of the old run which don't exist any more
+	removed int
+
+	total     scoreChange
+	files     []scoreChange
+	operators []scoreChange
+}
+
+// The score of a file or operator in both runs, nil where it has no mutants
+type scoreChange struct {
+	name          string
+	before, after *reportScore
+}
+
+func (change scoreChange) delta() float64 {
+	beforeScore, afterScore := 0.0, 0.0
+	if change.before != nil {
+		beforeScore = change.before.Score
+	}
+	if change.after != nil {
+		afterScore = change.after.Score
+	}
+
+	return afterScore - beforeScore
+}
+
+func (change scoreChange) hasChanged() bool {
+	if change.before == nil || change.after == nil {
+		return change.before != change.after
+	}
+
+	return *change.before != *change.after
+}
+
+// Reads a report written with --report
+func loadRunReport(path string) (*runReport, error) {
+	data, err := afero.ReadFile(FS, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var report runReport
+	err = json.Unmarshal(data, &report)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a mutation report: %v", path, err)
+	}
+
+	if report.SchemaVersion != reportSchemaVersion {
+		return nil, fmt.Errorf("%s has schema version %d, but only %d can be compared",
+			path, report.SchemaVersion, reportSchemaVersion)
+	}
+
+	for _, mutant := range report.Mutants {
+		if mutant.Key == "" {
+			// written before mutants had keys
+			setStableKeys(report.Mutants)
+			break
+		}
+	}
+
+	return &report, nil
+}
+
+func compareRunReports(before *runReport, after *runReport) *reportComparison {
+	comparison := &reportComparison{
+		total:     scoreChange{"total", &before.Total, &after.Total},
+		files:     compareScores(before.Files, after.Files),
+		operators: compareScores(before.Operators, after.Operators),
+	}
+
+	beforeMutants := make(map[string]reportMutant)
+	for _, mutant := range before.Mutants {
+		beforeMutants[mutant.Key] = mutant
+	}
+
+	survived := outcomeSurvived.String()
+	for _, mutant := range after.Mutants {
+		beforeMutant, existed := beforeMutants[mutant.Key]
+		delete(beforeMutants, mutant.Key)
+
+		switch {
+		case !existed && mutant.Outcome == survived:
+			comparison.newSurvivors = append(comparison.newSurvivors, mutant)
+		case !existed:
+			continue
+		case mutant.Outcome == survived && beforeMutant.Outcome != survived:
+			comparison.newlySurvived = append(comparison.newlySurvived, mutant)
+		case beforeMutant.Outcome == survived && isDetectedOutcome(mutant.Outcome):
+			comparison.newlyKilled = append(comparison.newlyKilled, mutant)
+		}
+	}
+	comparison.removed = len(beforeMutants)
+
+	return comparison
+}
+
+// Whether the outcome of a report mutant is a detected one, see mutantOutcome.isDetected
+func isDetectedOutcome(name string) bool {
+	outcome, ok := parseOutcome(name)
+	return ok && outcome.isDetected()
+}
+
+func compareScores(before map[string]*reportScore, after map[string]*reportScore) []scoreChange {
+	names := make(map[string]struct{})
+	for name := range before {
+		names[name] = struct{}{}
+	}
+	for name := range after {
+		names[name] = struct{}{}
+	}
+
+	var changes []scoreChange
+	for name := range names {
+		changes = append(changes, scoreChange{name, before[name], after[name]})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].name < changes[j].name
+	})
+
+	return changes
+}
+
+func logScoreChange(kind string, change scoreChange) {
+	fields := log.Fields{kind: change.name, "change": fmt.Sprintf("%+f", change.delta())}
+	if change.before != nil {
+		fields["score_before"] = change.before.Score
+		fields["survived_before"] = change.before.Survived
+	}
+	if change.after != nil {
+		fields["score_after"] = change.after.Score
+		fields["survived_after"] = change.after.Survived
+	}
+
+	log.WithFields(fields).Info("Mutation score changed.")
+}
+
+func logComparedMutant(message string, mutant reportMutant) {
+	log.WithFields(log.Fields{"id": mutant.Id, "key": mutant.Key, "file": mutant.File,
+		"line": mutant.Line, "operator": mutant.Operator}).Info(message)
+	log.Info(mutant.Diff)
+}
+
+func printComparison(comparison *reportComparison) {
+	for _, mutant := range comparison.newlySurvived {
+		logComparedMutant("Mutant survives which was detected before.", mutant)
+	}
+	for _, mutant := range comparison.newSurvivors {
+		logComparedMutant("New mutant survives.", mutant)
+	}
+	for _, mutant := range comparison.newlyKilled {
+		logComparedMutant("Mutant is detected which survived before.", mutant)
+	}
+
+	for _, change := range comparison.files {
+		if change.hasChanged() {
+			logScoreChange("file", change)
+		}
+	}
+	for _, change := range comparison.operators {
+		if change.hasChanged() {
+			logScoreChange("operator", change)
+		}
+	}
+	logScoreChange("total", comparison.total)
+
+	log.WithFields(log.Fields{
+		"newly_survived": len(comparison.newlySurvived),
+		"new_survivors":  len(comparison.newSurvivors),
+		"newly_killed":   len(comparison.newlyKilled),
+		"removed":        comparison.removed,
+	}).Info("Compared mutation reports.")
+}
+
+// Compares the reports of an old and a new run, given as two paths
+func compareReports(paths []string) int {
+	if len(paths) != 2 {
+		return exitError("compare needs the reports of two runs, the old one first, but got %d", len(paths))
+	}
+
+	before, err := loadRunReport(paths[0])
+	if err != nil {
+		return exitError(err.Error())
+	}
+
+	after, err := loadRunReport(paths[1])
+	if err != nil {
+		return exitError(err.Error())
+	}
+
+	printComparison(compareRunReports(before, after))
+
+	return returnOk
+}
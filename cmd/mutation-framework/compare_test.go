@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetStableKeys(t *testing.T) {
+	diff := "--- a/raft.go\n+++ b/raft.go\n@@ -7,1 +7,1 @@\n-\tif a < b {\n+\tif true {\n"
+	// the same code is mutated, but lines were added above it
+	shifted := "--- a/raft.go\n+++ b/raft.go\n@@ -9,1 +9,1 @@\n-  if a < b {\n+  if true {\n"
+
+	before := []reportMutant{
+		{Id: "raft.go.branch-if.3", File: "raft.go", Operator: "branch/if", Line: 7, Diff: diff},
+		{Id: "raft.go.branch-if.4", File: "raft.go", Operator: "branch/if", Line: 20, Diff: diff},
+	}
+	after := []reportMutant{
+		{Id: "raft.go.branch-if.5", File: "raft.go", Operator: "branch/if", Line: 9, Diff: shifted},
+		{Id: "raft.go.branch-if.6", File: "raft.go", Operator: "branch/if", Line: 22, Diff: shifted},
+	}
+
+	setStableKeys(before)
+	setStableKeys(after)
+
+	assert.Equal(t, before[0].Key, after[0].Key)
+	assert.Equal(t, before[1].Key, after[1].Key)
+	assert.NotEqual(t, before[0].Key, before[1].Key)
+	assert.Regexp(t, `^raft.go:branch/if:[0-9a-f]{12}#1$`, before[0].Key)
+}
+
+func TestCompareRunReports(t *testing.T) {
+	mutant := func(key string, outcome mutantOutcome) reportMutant {
+		return reportMutant{Id: key, Key: key, Outcome: outcome.String()}
+	}
+
+	before := &runReport{
+		Total: reportScore{Score: 0.5},
+		Files: map[string]*reportScore{"raft.go": {Score: 0.5, Survived: 2}, "gone.go": {Score: 1}},
+		Mutants: []reportMutant{
+			mutant("a", outcomeKilled),
+			mutant("b", outcomeSurvived),
+			mutant("c", outcomeSurvived),
+			mutant("d", outcomeKilled),
+			mutant("removed", outcomeKilled),
+		},
+	}
+	after := &runReport{
+		Total: reportScore{Score: 0.6},
+		Files: map[string]*reportScore{"raft.go": {Score: 0.6, Survived: 1}, "new.go": {Score: 0}},
+		Mutants: []reportMutant{
+			mutant("a", outcomeSurvived),
+			mutant("b", outcomeTimedOut),
+			mutant("c", outcomeSurvived),
+			mutant("d", outcomeKilled),
+			mutant("e", outcomeSurvived),
+			mutant("f", outcomeKilled),
+		},
+	}
+
+	comparison := compareRunReports(before, after)
+
+	assert.Equal(t, []reportMutant{mutant("a", outcomeSurvived)}, comparison.newlySurvived)
+	assert.Equal(t, []reportMutant{mutant("e", outcomeSurvived)}, comparison.newSurvivors)
+	assert.Equal(t, []reportMutant{mutant("b", outcomeTimedOut)}, comparison.newlyKilled)
+	assert.Equal(t, 1, comparison.removed)
+
+	assert.Len(t, comparison.files, 3)
+	assert.Equal(t, "gone.go", comparison.files[0].name)
+	assert.Nil(t, comparison.files[0].after)
+	assert.InDelta(t, -1.0, comparison.files[0].delta(), 0.0001)
+	assert.InDelta(t, 0.1, comparison.files[2].delta(), 0.0001)
+	assert.True(t, comparison.files[2].hasChanged())
+	assert.InDelta(t, 0.1, comparison.total.delta(), 0.0001)
+}
+
+func TestLoadRunReport(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	report := runReport{SchemaVersion: reportSchemaVersion, Mutants: []reportMutant{{Id: "raft.go.branch-if.0", File: "raft.go"}}}
+	data, _ := json.Marshal(report)
+	afero.WriteFile(FS, "/old.json", data, 0644)
+	afero.WriteFile(FS, "/broken.json", []byte("{"), 0644)
+	afero.WriteFile(FS, "/future.json", []byte(`{"schema_version": 99}`), 0644)
+
+	loaded, err := loadRunReport("/old.json")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, loaded.Mutants[0].Key)
+
+	_, err = loadRunReport("/broken.json")
+	assert.NotNil(t, err)
+	_, err = loadRunReport("/future.json")
+	assert.NotNil(t, err)
+
+	assert.Equal(t, returnError, compareReports([]string{"/old.json"}))
+	assert.Equal(t, returnOk, compareReports([]string{"/old.json", "/old.json"}))
+
+	// comparing logs as verbosely as asked, even though there is no config
+	defer log.SetLevel(log.GetLevel())
+	log.SetLevel(log.InfoLevel)
+	exit, exitCode := checkArguments([]string{"--debug", "compare", "/old.json", "/old.json"}, &Args{})
+	assert.True(t, exit)
+	assert.Equal(t, returnOk, exitCode)
+	assert.Equal(t, log.DebugLevel, log.GetLevel())
+}
+
+func TestIsDetectedOutcome(t *testing.T) {
+	assert.True(t, isDetectedOutcome("killed"))
+	assert.True(t, isDetectedOutcome("timed out"))
+	assert.False(t, isDetectedOutcome("survived"))
+	assert.False(t, isDetectedOutcome("error"))
+	assert.False(t, isDetectedOutcome("unknown"))
+}
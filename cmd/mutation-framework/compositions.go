@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Name of the folder inside a mutant which holds the node logs of every composition
+const compositionsFolder = "compositions"
+
+// The compositions every mutant is tested with, the first composition nodes unless configured.
+// With network faults, every composition is followed by the same composition with faults.
+func (test *Test) getCompositions() []Composition {
+	compositions := test.Compositions
+	if len(compositions) == 0 {
+		nodes := make([]int, test.getComposition())
+		for i := range nodes {
+			nodes[i] = i
+		}
+		compositions = []Composition{{Name: fmt.Sprintf("first-%d", len(nodes)), Nodes: nodes}}
+	}
+
+	if !test.usesFaults() {
+		return compositions
+	}
+
+	var withFaults []Composition
+	for _, composition := range compositions {
+		faulty := composition
+		faulty.Name += faultsSuffix
+		faulty.faults = true
+		withFaults = append(withFaults, composition, faulty)
+	}
+
+	return withFaults
+}
+
+// Whether the outcome of every composition is reported, rather than only the verdict
+func (test *Test) reportsCompositions() bool {
+	return len(test.Compositions) > 0 || test.usesFaults()
+}
+
+func validateCompositions(test *Test) error {
+	if len(test.Compositions) > 0 && test.Composition != 0 {
+		return fmt.Errorf("composition and compositions can't be set together")
+	}
+
+	names := make(map[string]struct{})
+	for _, composition := range test.Compositions {
+		if composition.Name == "" {
+			return fmt.Errorf("every composition needs a name")
+		}
+		if _, ok := names[composition.Name]; ok {
+			return fmt.Errorf("composition %q is there twice", composition.Name)
+		}
+		names[composition.Name] = struct{}{}
+
+		if len(composition.Nodes) == 0 {
+			return fmt.Errorf("composition %q has no nodes", composition.Name)
+		}
+		for _, node := range composition.Nodes {
+			if node < 0 || node >= test.Nodes {
+				return fmt.Errorf("composition %q has node %d, but the nodes are 0 to %d",
+					composition.Name, node, test.Nodes-1)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Builds the mutant binary and runs the tests against a cluster for every composition.
+// The verdict is the one of the composition which detects the mutant, see combineCompositionRuns.
+func runTestsForCompositions(config *MutationConfig, mutantInfo MutantInfo, originalFilePath string,
+	workspace *mutantWorkspace) *testRun {
+	mutantBinary := appendFolder(mutantInfo.mutantDirPathAbsPath, nodeBinary)
+	if outcome, ok := buildNodeBinary(config, workspace, mutantBinary); !ok {
+		return &testRun{outcome: outcome}
+	}
+
+	var runs []*testRun
+	outcomes := make(map[string]mutantOutcome)
+	for _, composition := range config.Test.getCompositions() {
+		run := runTestsForComposition(config, mutantInfo, originalFilePath, workspace, mutantBinary, composition)
+		log.WithFields(log.Fields{"mutant": mutantInfo.mutantDirPathAbsPath, "composition": composition.Name,
+			"outcome": run.outcome.String()}).Debug("Finished composition.")
+
+		runs = append(runs, run)
+		outcomes[composition.Name] = run.outcome
+	}
+
+	verdict := combineCompositionRuns(runs)
+	if config.Test.reportsCompositions() {
+		verdict.compositions = outcomes
+	}
+
+	return verdict
+}
+
+// Launches the cluster of the composition and runs the test command against it
+// The nodes of configured compositions log to a folder of the composition in the mutant.
+func runTestsForComposition(config *MutationConfig, mutantInfo MutantInfo, originalFilePath string,
+	workspace *mutantWorkspace, mutantBinary string, composition Composition) *testRun {
+	logDir := mutantInfo.mutantDirPathAbsPath
+	if config.Test.reportsCompositions() {
+		logDir = appendFolder(appendFolder(logDir, compositionsFolder), composition.Name)
+		if err := FS.MkdirAll(logDir, 0755); err != nil {
+			log.WithField("mutant", mutantInfo.mutantDirPathAbsPath).Error(err)
+			return &testRun{outcome: outcomeError}
+		}
+	}
+
+	proxy, err := startFaultProxy(config, workspace, composition.faults)
+	if err != nil {
+		log.WithField("mutant", mutantInfo.mutantDirPathAbsPath).Error(err)
+		return &testRun{outcome: outcomeError}
+	}
+	defer stopFaultProxy(proxy)
+
+	workspace.env = setEnv(workspace.env, "MUTATE_COMPOSITION", composition.Name)
+	cluster, err := launchCluster(config, workspace, logDir, mutantBinary, composition.Nodes)
+	if err != nil {
+		log.WithField("mutant", mutantInfo.mutantDirPathAbsPath).Error(err)
+		return &testRun{outcome: outcomeError}
+	}
+	defer cluster.stop()
+
+	return runTestCommand(config, mutantInfo, originalFilePath, workspace)
+}
+
+// Orders outcomes by how much they say about the mutant, a kill says the most.
+// A survivor is only certain if no composition failed to run.
+var compositionRank = map[mutantOutcome]int{
+	outcomeKilled:       0,
+	outcomeTimedOut:     1,
+	outcomeCrashed:      2,
+	outcomeError:        3,
+	outcomeSurvived:     4,
+	outcomeNotCompiling: 5,
+	outcomeEquivalent:   6,
+}
+
+// The run of the compositions that decides the verdict: a composition detecting the mutant
+// is enough to detect it, the first one on a tie. The duration is the one of all runs.
+func combineCompositionRuns(runs []*testRun) *testRun {
+	best := runs[0]
+	total := best.duration
+	for _, run := range runs[1:] {
+		if compositionRank[run.outcome] < compositionRank[best.outcome] {
+			best = run
+		}
+		total += run.duration
+	}
+
+	verdict := *best
+	verdict.duration = total
+	return &verdict
+}
+
+// Outcomes of the compositions by name, for the cache and the journal
+func formatCompositions(compositions map[string]mutantOutcome) map[string]string {
+	if compositions == nil {
+		return nil
+	}
+
+	names := make(map[string]string, len(compositions))
+	for name, outcome := range compositions {
+		names[name] = outcome.String()
+	}
+
+	return names
+}
+
+func parseCompositions(names map[string]string) (map[string]mutantOutcome, bool) {
+	if names == nil {
+		return nil, true
+	}
+
+	compositions := make(map[string]mutantOutcome, len(names))
+	for name, outcomeName := range names {
+		outcome, ok := parseOutcome(outcomeName)
+		if !ok {
+			return nil, false
+		}
+		compositions[name] = outcome
+	}
+
+	return compositions, true
+}
+
+// Scores of every composition and of every role of the mutated nodes, from the outcome
+// of each mutant in each composition
+func getCompositionStats(config *MutationConfig, results []*mutantResult) (compositions map[string]*mutationStats,
+	roles map[string]*mutationStats) {
+	compositions = make(map[string]*mutationStats)
+	roles = make(map[string]*mutationStats)
+
+	for _, result := range results {
+		for _, composition := range config.Test.getCompositions() {
+			outcome, ok := result.compositions[composition.Name]
+			if !ok {
+				continue
+			}
+
+			recordIn(compositions, composition.Name, outcome)
+			if composition.Role != "" {
+				recordIn(roles, composition.Role, outcome)
+			}
+		}
+	}
+
+	return compositions, roles
+}
+
+// Logs which compositions detected every mutant, and the scores of the compositions and node roles
+func printCompositionMatrix(config *MutationConfig, results []*mutantResult) {
+	for _, result := range results {
+		if result.compositions == nil {
+			continue
+		}
+
+		var detectedBy []string
+		for _, composition := range config.Test.getCompositions() {
+			if outcome, ok := result.compositions[composition.Name]; ok && outcome.isDetected() {
+				detectedBy = append(detectedBy, composition.Name)
+			}
+		}
+
+		log.WithFields(log.Fields{
+			"mutant":      result.mutant.mutationFileAbsPath,
+			"outcome":     result.outcome.String(),
+			"detected_by": strings.Join(detectedBy, ","),
+		}).Info("Compositions detecting the mutant.")
+	}
+
+	compositions, roles := getCompositionStats(config, results)
+	for _, composition := range config.Test.getCompositions() {
+		if stats, ok := compositions[composition.Name]; ok {
+			log.WithField("composition", composition.Name).
+				Info(fmt.Sprintf("For this composition, the mutation score is %f (%d passed, %d failed, total is %d)",
+					stats.Score(), stats.passed, stats.failed, stats.Total()))
+		}
+	}
+
+	names := make([]string, 0, len(roles))
+	for name := range roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		stats := roles[name]
+		log.WithField("node_role", name).
+			Info(fmt.Sprintf("With the mutant on this role, the mutation score is %f (%d passed, %d failed, total is %d)",
+				stats.Score(), stats.passed, stats.failed, stats.Total()))
+	}
+}
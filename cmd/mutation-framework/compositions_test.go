@@ -0,0 +1,121 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const compositionsConfig = `{"project_root":"home","test":{"nodes":3,"commands":{"launch":"./start.sh"},"compositions":[
+	{"name":"leader","nodes":[0],"role":"leader"},
+	{"name":"follower","nodes":[1],"role":"follower"},
+	{"name":"majority","nodes":[1,2],"role":"follower"}]}}`
+
+func TestCompositionsConfig(t *testing.T) {
+	config, err := parseAndValidateConfig([]byte(compositionsConfig))
+	assert.Nil(t, err)
+	assert.Len(t, config.Test.getCompositions(), 3)
+	assert.Equal(t, []int{1, 2}, config.Test.getCompositions()[2].Nodes)
+
+	config, err = parseAndValidateConfig([]byte(`{"project_root":"home","test":{"nodes":3,"composition":2,"commands":{"launch":"./start.sh"}}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []Composition{{Name: "first-2", Nodes: []int{0, 1}}}, config.Test.getCompositions())
+
+	for _, invalid := range []string{
+		`{"project_root":"home","test":{"compositions":[{"name":"leader","nodes":[0]}]}}`,
+		`{"project_root":"home","test":{"nodes":3,"composition":1,"commands":{"launch":"./start.sh"},"compositions":[{"name":"leader","nodes":[0]}]}}`,
+		`{"project_root":"home","test":{"nodes":3,"commands":{"launch":"./start.sh"},"compositions":[{"nodes":[0]}]}}`,
+		`{"project_root":"home","test":{"nodes":3,"commands":{"launch":"./start.sh"},"compositions":[{"name":"leader","nodes":[]}]}}`,
+		`{"project_root":"home","test":{"nodes":3,"commands":{"launch":"./start.sh"},"compositions":[{"name":"leader","nodes":[3]}]}}`,
+		`{"project_root":"home","test":{"nodes":3,"commands":{"launch":"./start.sh"},"compositions":[{"name":"a","nodes":[0]},{"name":"a","nodes":[1]}]}}`,
+	} {
+		_, err = parseAndValidateConfig([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestCombineCompositionRuns(t *testing.T) {
+	verdict := combineCompositionRuns([]*testRun{
+		{outcome: outcomeSurvived, duration: time.Second},
+		{outcome: outcomeTimedOut, duration: time.Second},
+		{outcome: outcomeKilled, failedTests: []string{"TestElection"}, duration: time.Second},
+	})
+	assert.Equal(t, outcomeKilled, verdict.outcome)
+	assert.Equal(t, []string{"TestElection"}, verdict.failedTests)
+	assert.Equal(t, 3*time.Second, verdict.duration)
+
+	verdict = combineCompositionRuns([]*testRun{{outcome: outcomeNotCompiling}, {outcome: outcomeSurvived}})
+	assert.Equal(t, outcomeSurvived, verdict.outcome)
+
+	// a composition that could not run may have detected the mutant
+	verdict = combineCompositionRuns([]*testRun{{outcome: outcomeSurvived}, {outcome: outcomeError}})
+	assert.Equal(t, outcomeError, verdict.outcome)
+	verdict = combineCompositionRuns([]*testRun{{outcome: outcomeError}, {outcome: outcomeKilled}})
+	assert.Equal(t, outcomeKilled, verdict.outcome)
+}
+
+func TestCompositionInfrastructureError(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config, err := parseAndValidateConfig([]byte(compositionsConfig))
+	assert.Nil(t, err)
+	// the fault proxy can't start without the roles file
+	config.Mutate.Roles.File = "/missing.json"
+	config.Test.Faults.PortOffset = 10000
+
+	mutant := MutantInfo{originalFileRelativePath: "raft.go", mutantDirPathAbsPath: "/project/mutants/raft.go/a"}
+	run := runTestsForComposition(config, mutant, "/project/raft.go", &mutantWorkspace{}, "/project/mutants/raft.go/a/node",
+		config.Test.getCompositions()[0])
+	assert.Equal(t, outcomeError, run.outcome)
+}
+
+func TestCompositionScores(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config, err := parseAndValidateConfig([]byte(compositionsConfig))
+	assert.Nil(t, err)
+	config.ProjectRoot = "/project"
+	config.Mutate.MutantFolder = "mutants/"
+
+	mutant := func(name string, compositions map[string]mutantOutcome) *mutantResult {
+		afero.WriteFile(FS, "/project/mutants/raft.go/"+name+"/raft.go", []byte("package raft // "+name+"\n"), 0644)
+		return newMutantResult(MutantInfo{originalFileRelativePath: "raft.go", operator: "branch/if",
+			mutantDirPathAbsPath: "/project/mutants/raft.go/" + name, mutationFileAbsPath: "/project/mutants/raft.go/" + name + "/raft.go"},
+			&testRun{outcome: outcomeKilled, compositions: compositions})
+	}
+
+	results := []*mutantResult{
+		// only noticed when a majority runs the mutant
+		mutant("a", map[string]mutantOutcome{"leader": outcomeSurvived, "follower": outcomeSurvived, "majority": outcomeKilled}),
+		mutant("b", map[string]mutantOutcome{"leader": outcomeKilled, "follower": outcomeKilled, "majority": outcomeKilled}),
+	}
+
+	report := newRunReport(config, results, map[string]*mutationStats{})
+	assert.Equal(t, 0.5, report.Compositions["leader"].Score)
+	assert.Equal(t, 0.5, report.Compositions["follower"].Score)
+	assert.Equal(t, 1.0, report.Compositions["majority"].Score)
+	assert.Equal(t, 0.5, report.NodeRoles["leader"].Score)
+	assert.Equal(t, 0.75, report.NodeRoles["follower"].Score)
+	assert.Equal(t, map[string]string{"leader": "survived", "follower": "survived", "majority": "killed"},
+		report.Mutants[0].Compositions)
+
+	// the outcomes of the compositions survive a resumed run
+	journal, err := openRunJournal(config)
+	assert.Nil(t, err)
+	journal.record(config, results[0])
+
+	config.Test.Resume = true
+	journal, err = openRunJournal(config)
+	assert.Nil(t, err)
+	resumed, ok := journal.lookup(config, results[0].mutant)
+	assert.True(t, ok)
+	assert.Equal(t, results[0].compositions, resumed.compositions)
+
+	report = newRunReport(config, []*mutantResult{mutant("c", nil)}, map[string]*mutationStats{})
+	assert.Nil(t, report.Compositions)
+	assert.Nil(t, report.NodeRoles)
+}
@@ -12,6 +12,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"path/filepath"
+	"time"
 )
 
 // Cannot extend types in other packages
@@ -32,8 +33,30 @@ type MutationConfig struct {
 type Test struct {
 	Disable bool `json:"disable"`
 	Timeout      uint   `json:"timeout"`
-	Composition  int    `json:"composition"`
+	Composition  int    `json:"composition"` // number of nodes of the cluster which run the mutant
+	Nodes        int    `json:"nodes"`       // number of nodes the launch command starts for each mutant
+	NodePackage  string `json:"node_package"` // main package of the node binary, relative to the project root
+	Compositions []Composition `json:"compositions"` // every mutant is tested once per composition if set
+	Faults       Faults        `json:"faults"`       // network faults injected between the nodes, see faults.go
+	Workers      int    `json:"workers"`
+	TimeoutFactor float64 `json:"timeout_factor"`
+	Repeat       int    `json:"repeat"`
+	RerunSurvivors bool `json:"rerun_survivors"`
+	KillMatrix   string `json:"kill_matrix"` // path to export the kill matrix to, as .csv or .json
+	Report       string `json:"report"`      // path to write the JSON report of all mutants to
+	HtmlReport   string `json:"html_report"` // path to write the HTML report of all mutants to
+	JunitReport  string `json:"junit_report"` // path to write the mutants as JUnit XML to
+	ElementsReport string `json:"elements_report"` // path to write the mutation-testing-elements JSON to
+	MinScore     *float64 `json:"min_score"`     // between 0 and 1, for all files together
+	MinScores    map[string]float64 `json:"min_scores"` // by relative file path, package or directory
+	MaxSurvivors *int     `json:"max_survivors"`
+	Cache        string   `json:"cache"` // path of the result cache, verdicts in it are not executed again
+	Resume       bool     `json:"resume"` // skips the mutants the journal of the last run has verdicts for
+	DetectEquivalent bool `json:"detect_equivalent"` // mutants compiling to the same code as the original are not tested
 	Commands    Commands `json:"commands"`
+
+	// timeout derived from the baseline run
+	mutantTimeout time.Duration
 }
 
 type Mutate struct {
@@ -43,6 +66,15 @@ type Mutate struct {
 	FilesToExclude []string   `json:"files_to_exclude"`
 	MutantFolder string `json:"mutant_folder"`
 	Overwrite bool `json:"overwrite"`
+	Since string `json:"since"` // git revision, only lines changed since then are mutated
+	Overlay bool `json:"overlay"` // only the mutated file is stored and go test -overlay replaces the original by it
+	Workspace string `json:"workspace"` // how mutants get the unchanged files: copy (default), hardlink or reflink
+	Suppressions string `json:"suppressions"` // path of the file of mutants that are not created
+	Roles Roles `json:"roles"` // only the lines of these node roles are mutated
+	Schemata bool `json:"schemata"` // the mutants of a file are woven into one source and switched at runtime
+
+	// lines changed since the revision, by relative file path
+	changedLines map[string][]lineRange
 }
 
 
@@ -50,14 +82,67 @@ type Mutate struct {
 // Project Directory is necessary
 // If mutant folder doesn't start with /, it is taken to be relative
 
+// Node roles whose lines are mutated, from a file like node_roles.json
+type Roles struct {
+	File  string   `json:"file"`
+	Names []string `json:"names"` // all roles of the file if empty
+}
+
+// Nodes of the cluster which run the mutant, like only the leader or a majority
+type Composition struct {
+	Name  string `json:"name"`
+	Nodes []int  `json:"nodes"` // indexes of the mutated nodes
+	Role  string `json:"role"`  // role of the mutated nodes, for the scores per node role
+
+	// whether the network faults are injected, see getCompositions
+	faults bool
+}
+
+// Proxies between the nodes which inject network faults. With a schedule, every composition
+// is tested once without and once with the faults.
+type Faults struct {
+	Links      []FaultLink  `json:"links"`
+	PortOffset int          `json:"port_offset"` // every address of the roles file gets a link to its port plus this
+	Schedule   []FaultEntry `json:"schedule"`
+	Seed       int64        `json:"seed"`
+}
+
+// Forwards what the nodes send to listen on to the node listening on upstream
+type FaultLink struct {
+	Name     string `json:"name"`
+	Protocol string `json:"protocol"` // tcp (default) or udp
+	Listen   string `json:"listen"`
+	Upstream string `json:"upstream"`
+}
+
+// A fault of the schedule, timed from the launch of the cluster
+type FaultEntry struct {
+	Links      []string `json:"links"` // names of links or roles, all links if empty
+	StartMs    int      `json:"start_ms"`
+	DurationMs int      `json:"duration_ms"` // until the tests end if 0
+	DelayMs    int      `json:"delay_ms"`
+	JitterMs   int      `json:"jitter_ms"`
+	Drop       float64  `json:"drop"` // probabilities for each message
+	Duplicate  float64  `json:"duplicate"`
+	Reorder    float64  `json:"reorder"`
+	Partition  bool     `json:"partition"`
+}
+
 type Commands struct {
 	Test    string `json:"test"`
 	Build string `json:"build"`
 	CleanUp string `json:"clean_up"`
+	Launch  string `json:"launch"` // starts one node of the cluster, see Test.Nodes
 }
 
 const DefaultMutationFolder = "mutants/"
 
+// Number of mutants executed at the same time if workers is not set
+const DefaultWorkers = 1
+
+// Seconds a command may run if timeout is not set
+const DefaultTimeout = 10
+
 // Bundle mutation operators together with their names
 func (operator *Operator) UnmarshalJSON(data []byte) error {
 	var mutatorName string
@@ -95,6 +180,21 @@ func getConfig(configFilePath string) (*MutationConfig, error) {
 	return parseConfig(data)
 }
 
+// Reads the config file and applies the command-line arguments to it before validating it
+func loadConfig(opts *Args) (*MutationConfig, error) {
+	config, err := getConfig(opts.General.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	consolidateArgsIntoConfig(opts, config)
+	if err := validateImportantConfigFields(config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
 func parseConfig(data []byte) (*MutationConfig, error) {
 	var config MutationConfig
 	err := json.Unmarshal([]byte(data), &config)
@@ -114,11 +214,6 @@ func (config *MutationConfig) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	err = validateImportantConfigFields(config)
-	if err != nil {
-		return err
-	}
-
 	appendMutantFolderSlashOrReplaceWithDefault(config)
 	expandWildCards(config)
 	config.ProjectRoot = appendSlash(config.ProjectRoot)
@@ -143,6 +238,7 @@ func (config *MutationConfig) toString() (string, error) {
 	return string(configString), nil
 }
 
+// Validates the config once the command-line arguments are applied to it, see loadConfig
 func validateImportantConfigFields(config *MutationConfig) error {
 	noFilesSpecified := func(config *MutationConfig) bool {
 		return !config.Mutate.Disable &&
@@ -167,6 +263,47 @@ func validateImportantConfigFields(config *MutationConfig) error {
 		log.Debug( "Did you intend for mutant folder to have path separator prefix?\n")
 	}
 
+	switch config.Mutate.getWorkspaceStrategy() {
+	case workspaceCopy, workspaceHardlink, workspaceReflink:
+	default:
+		return fmt.Errorf("workspace must be copy, hardlink or reflink, but is %q", config.Mutate.Workspace)
+	}
+
+	if config.Test.MinScore != nil && (*config.Test.MinScore < 0 || *config.Test.MinScore > 1) {
+		return fmt.Errorf("min_score must be between 0 and 1, but is %f", *config.Test.MinScore)
+	}
+
+	for name, minScore := range config.Test.MinScores {
+		if minScore < 0 || minScore > 1 {
+			return fmt.Errorf("min_scores of %s must be between 0 and 1, but is %f", name, minScore)
+		}
+	}
+
+	if config.Test.usesCluster() {
+		if config.Test.Nodes < 1 {
+			return fmt.Errorf("nodes must be at least 1 with a launch command, but is %d", config.Test.Nodes)
+		}
+		if config.Test.getComposition() < 1 || config.Test.getComposition() > config.Test.Nodes {
+			return fmt.Errorf("composition must be between 1 and the %d nodes, but is %d",
+				config.Test.Nodes, config.Test.Composition)
+		}
+		if err := validateCompositions(&config.Test); err != nil {
+			return err
+		}
+		// the nodes of mutants executed in parallel would share their addresses
+		if config.Test.getWorkers() > 1 {
+			return fmt.Errorf("workers must be 1 with a launch command, but is %d", config.Test.Workers)
+		}
+	}
+
+	if len(config.Test.Compositions) > 0 && !config.Test.usesCluster() {
+		return fmt.Errorf("compositions need a launch command")
+	}
+
+	if err := validateFaults(config); err != nil {
+		return err
+	}
+
 	if config.Test.Commands == (Commands{}) {
 		log.Debug("Did you mean for Commands to be empty?")
 	}
@@ -208,6 +345,48 @@ func appendSlash(path string) string {
 	return path
 }
 
+// Number of mutants that should be executed at the same time
+func (test *Test) getWorkers() int {
+	if test.Workers < 1 {
+		return DefaultWorkers
+	}
+
+	return test.Workers
+}
+
+// How a mutant gets the files of the project that are not mutated
+type workspaceStrategy string
+
+const (
+	workspaceCopy     workspaceStrategy = "copy"
+	workspaceHardlink workspaceStrategy = "hardlink"
+	workspaceReflink  workspaceStrategy = "reflink"
+)
+
+// Mutants of a schemata are overlay mutants as well, the overlay is only swapped for the schemata
+func (mutate *Mutate) usesOverlay() bool {
+	return mutate.Overlay || mutate.Schemata
+}
+
+// How the mutants are stored: "copy", "overlay" or "schemata", see --overlay and --schemata
+func (mutate *Mutate) getStorage() string {
+	if mutate.Schemata {
+		return "schemata"
+	} else if mutate.Overlay {
+		return "overlay"
+	}
+
+	return "copy"
+}
+
+func (mutate *Mutate) getWorkspaceStrategy() workspaceStrategy {
+	if mutate.Workspace == "" {
+		return workspaceCopy
+	}
+
+	return workspaceStrategy(mutate.Workspace)
+}
+
 // Include files in include, then exclude files from exclude
 func (config *MutationConfig) getIncludedFiles() []string {
 	var filesToMutate = make(map[string]struct{},0)
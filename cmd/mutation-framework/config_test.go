@@ -6,6 +6,9 @@ import (
 	"github.com/amyjzhu/mutation-framework/mutator"
 	"go/types"
 	"go/ast"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 )
 
 // test that configs are properly loaded
@@ -33,16 +36,17 @@ func initialize() {
 	mutator.Register("mutator/mock", expectedMutator)
 
 	expectedConfig = MutationConfig{
-		false,
-		false,
-		"/home/",
-		Mutate{false, []Operator{{&expectedMutator, "mutator/mock"}},
-			[]string{"primary.go", "secondary.go"},
+		Verbose: false,
+		Json: false,
+		ProjectRoot: "/home/",
+		Mutate: Mutate{Disable: false,
+			Operators: []Operator{{&expectedMutator, "mutator/mock"}},
+			FilesToInclude: []string{"primary.go", "secondary.go"},
 			//[]string{},
-			nil,"mutants/",
-			false},
-		Test{false, 10, 1,
-		Commands{"go test", "", ""}}}
+			FilesToExclude: nil, MutantFolder: "mutants/",
+			Overwrite: false},
+		Test: Test{Disable: false, Timeout: 10, Composition: 1,
+		Commands: Commands{"go test", "", "", ""}}}
 }
 
 func TestJsonConfig(t *testing.T) {
@@ -113,6 +117,48 @@ func TestMinimalParseConfig(t *testing.T) {
 	assert.EqualValues(t, *actualConfig, expectedConfig)
 }
 
+// Parses the config and validates it, like loadConfig does once the arguments are applied
+func parseAndValidateConfig(data []byte) (*MutationConfig, error) {
+	config, err := parseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, validateImportantConfigFields(config)
+}
+
+func TestLoadConfigValidatesArguments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mutation-testing")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	configPath := filepath.Join(dir, "config.json")
+	assert.Nil(t, ioutil.WriteFile(configPath,
+		[]byte(`{"project_root":"home","test":{"nodes":3,"commands":{"launch":"./start.sh"}}}`), 0644))
+
+	opts := &Args{}
+	opts.General.ConfigPath = configPath
+	config, err := loadConfig(opts)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, config.Test.Nodes)
+
+	minScore := 80.0
+	for name, setArgument := range map[string]func(opts *Args){
+		"nodes":       func(opts *Args) { opts.Exec.Nodes = -1 },
+		"composition": func(opts *Args) { opts.Exec.Composition = 4 },
+		"workers":     func(opts *Args) { opts.Exec.Workers = 2 },
+		"min-score":   func(opts *Args) { opts.Exec.MinScore = &minScore },
+		"workspace":   func(opts *Args) { opts.Exec.Workspace = "symlink" },
+	} {
+		opts := &Args{}
+		opts.General.ConfigPath = configPath
+		setArgument(opts)
+
+		_, err := loadConfig(opts)
+		assert.Error(t, err, name)
+	}
+}
+
 func TestDefaultMutantFunctionality(t *testing.T) {
 	//initialize()
 	expectedConfig.Mutate.MutantFolder = ""
@@ -135,6 +181,12 @@ func TestMutantFolderFormatting(t *testing.T) {
 	assert.Equal(t, "deep fried pickles/", expectedConfig.Mutate.MutantFolder)
 }
 
+func TestGetWorkers(t *testing.T) {
+	assert.Equal(t, DefaultWorkers, (&Test{}).getWorkers())
+	assert.Equal(t, DefaultWorkers, (&Test{Workers: -3}).getWorkers())
+	assert.Equal(t, 4, (&Test{Workers: 4}).getWorkers())
+}
+
 func TestConcatAndAddSlashIfNeeded(t *testing.T) {
 	assert.Equal(t, "hello/world", concatAddingSlashIfNeeded("hello", "world"))
 	assert.Equal(t, "hello/world", concatAddingSlashIfNeeded("hello/", "world"))
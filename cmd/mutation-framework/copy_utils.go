@@ -12,18 +12,17 @@ import (
 
 func copyProject(config *MutationConfig, name string) (absoluteMutantPath string, err error) {
 	log.WithField("mutant", name).Debug("Copying into mutants folder.")
-	dir, err := os.Getwd()
-	if err != nil {
-		panic (err)
-	}
 
+	// copy the project root rather than the working directory, since the tests of the
+	// mutant are run inside this copy
 	projectName := appendFolder(getAbsoluteMutationFolderPath(config), name)
 
 	return projectName,
-		copyRecursive(config.Mutate.Overwrite, dir, projectName, config.Mutate.MutantFolder)
+		copyRecursive(config.Mutate.Overwrite, filepath.Clean(config.ProjectRoot), projectName,
+			config.Mutate.MutantFolder, config.Mutate.getWorkspaceStrategy())
 }
 
-func copyRecursive(overwrite bool, source string, dest string, mutantFolder string) error {
+func copyRecursive(overwrite bool, source string, dest string, mutantFolder string, strategy workspaceStrategy) error {
 	destFile, err := FS.Open(dest)
 	// did we get an error opening destination file?
 	if err != nil {
@@ -74,12 +73,12 @@ func copyRecursive(overwrite bool, source string, dest string, mutantFolder stri
 					continue
 				}
 
-				err = copyRecursive(overwrite, newSource, newDest, mutantFolder)
+				err = copyRecursive(overwrite, newSource, newDest, mutantFolder, strategy)
 				if err != nil {
 					return err
 				}
 			} else {
-				err = osutil.AferoCopyFile(FS, newSource, newDest)
+				err = copyFile(strategy, newSource, newDest)
 				if err != nil {
 					return err
 				}
@@ -89,6 +88,35 @@ func copyRecursive(overwrite bool, source string, dest string, mutantFolder stri
 	return nil
 }
 
+// Copies one file of the project into a workspace, linking it instead if the strategy says so
+// Falls back to copying where links are not possible, e.g. across file systems.
+func copyFile(strategy workspaceStrategy, source string, dest string) error {
+	// a file left by an earlier run may be a link of the source, which must not be written
+	err := FS.Remove(dest)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if _, ok := FS.(*afero.OsFs); ok {
+		switch strategy {
+		case workspaceHardlink:
+			err = osutil.LinkFile(source, dest)
+		case workspaceReflink:
+			err = osutil.ReflinkFile(source, dest)
+		default:
+			return osutil.AferoCopyFile(FS, source, dest)
+		}
+
+		if err == nil {
+			return nil
+		}
+		log.WithFields(log.Fields{"file": source, "strategy": strategy, "error": err}).
+			Debug("Could not link file, copying it instead.")
+	}
+
+	return osutil.AferoCopyFile(FS, source, dest)
+}
+
 func doNotCopyDir(dir string, innerFolder string) bool {
 	// don't copy git information or mutant folders
 	return dir == filepath.Clean(innerFolder) ||
@@ -114,22 +142,6 @@ func getFirstElementInPath(path string) string {
 	}
 }
 
-// Copy contents of one folder to another, ignoring mutants folder
-func moveAllContentsExceptMutantFolder(sourceFolder string, dest string, mutantFolder string) error {
-	log.WithFields(log.Fields{"source": sourceFolder, "dest": dest}).Info("Moving contents of folder.")
-	isNotMutantsFolder := func(name string) bool {
-		if strings.Contains(filepath.Clean(mutantFolder), string(os.PathSeparator)) {
-			// Get first part of path, which matches what you see in directory
-			// TODO absolute // paths
-			mutantFolder = strings.Split(mutantFolder, string(os.PathSeparator))[0]
-		}
-
-		return !(strings.Contains(name, mutantFolder) || strings.Contains(mutantFolder, name))
-	}
-
-	return copyFolderContents(sourceFolder, dest, isNotMutantsFolder)
-}
-
 // Copy all the contents of one folder to another folder i.e. cp src/* dest/
 // Creates destination if doesn't exist
 func copyFolderContents(sourceFolder string, destFolder string, pred func(name string) bool) error {
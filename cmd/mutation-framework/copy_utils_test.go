@@ -1,6 +1,10 @@
 package main
 
 import (
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 	"github.com/stretchr/testify/assert"
 	"github.com/spf13/afero"
@@ -47,7 +51,7 @@ func TestCopy(t *testing.T) {
 	FS.Mkdir(testFile + ".git", os.FileMode(700))
 	defer sample.Close()
 
-	err = copyRecursive(true, testFileParent, mutationPath, mutationFolder)
+	err = copyRecursive(true, testFileParent, mutationPath, mutationFolder, workspaceCopy)
 	assert.Nil(t, err)
 
 	// read from /tmp/mutation-testing/copied-mutants
@@ -95,7 +99,7 @@ func TestCopyWithoutOverwrite(t *testing.T) {
 	err := FS.MkdirAll(destination, os.FileMode(777)) // live dangerously
 	assert.Nil(t, err)
 
-	err = copyRecursive(false, testFileParent, destination, "mutant")
+	err = copyRecursive(false, testFileParent, destination, "mutant", workspaceCopy)
 	assert.NotNil(t, err)
 }
 
@@ -133,4 +137,58 @@ func TestSimpleCopyFolderContents(t *testing.T) {
 	}
 
 	assert.ElementsMatch(t, expectedCopiedFiles, actualCopiedFiles)
-}
\ No newline at end of file
+}
+
+func TestCopyFileStrategies(t *testing.T) {
+	FS = afero.NewOsFs()
+
+	dir, err := ioutil.TempDir("", "mutation-testing")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	original := filepath.Join(dir, "original.go")
+	assert.Nil(t, ioutil.WriteFile(original, []byte("package original\n"), 0644))
+
+	for _, strategy := range []workspaceStrategy{workspaceCopy, workspaceHardlink, workspaceReflink} {
+		dest := filepath.Join(dir, string(strategy)+".go")
+		assert.Nil(t, copyFile(strategy, original, dest))
+		// copying over an earlier workspace must not write through a link
+		assert.Nil(t, copyFile(strategy, original, dest))
+
+		data, err := ioutil.ReadFile(dest)
+		assert.Nil(t, err)
+		assert.Equal(t, "package original\n", string(data))
+
+		originalInfo, _ := os.Stat(original)
+		destInfo, _ := os.Stat(dest)
+		assert.Equal(t, strategy == workspaceHardlink, os.SameFile(originalInfo, destInfo))
+	}
+}
+
+func TestWriteMutantFileDoesNotWriteThroughHardlink(t *testing.T) {
+	FS = afero.NewOsFs()
+
+	dir, err := ioutil.TempDir("", "mutation-testing")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	original := filepath.Join(dir, "original.go")
+	assert.Nil(t, ioutil.WriteFile(original, []byte("package original\n"), 0644))
+
+	mutant := filepath.Join(dir, "mutant.go")
+	assert.Nil(t, copyFile(workspaceHardlink, original, mutant))
+
+	fset := token.NewFileSet()
+	src, err := parser.ParseFile(fset, mutant, nil, 0)
+	assert.Nil(t, err)
+	src.Name.Name = "mutated"
+
+	_, source, _, err := printMutant(make(map[string]struct{}), fset, src)
+	assert.Nil(t, err)
+	assert.Nil(t, writeMutantFile(mutant, source))
+
+	data, _ := ioutil.ReadFile(original)
+	assert.Equal(t, "package original\n", string(data))
+	data, _ = ioutil.ReadFile(mutant)
+	assert.Equal(t, "package mutated\n", string(data))
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// The report schema of mutation-testing-elements, which Stryker dashboards and
+// other mutation report viewers read, see github.com/stryker-mutator/mutation-testing-elements
+type elementsReport struct {
+	SchemaVersion string                   `json:"schemaVersion"`
+	Thresholds    elementsThresholds       `json:"thresholds"`
+	ProjectRoot   string                   `json:"projectRoot,omitempty"`
+	Files         map[string]*elementsFile `json:"files"`
+}
+
+type elementsThresholds struct {
+	High int `json:"high"`
+	Low  int `json:"low"`
+}
+
+type elementsFile struct {
+	Language string           `json:"language"`
+	Source   string           `json:"source"`
+	Mutants  []elementsMutant `json:"mutants"`
+}
+
+type elementsMutant struct {
+	Id           string           `json:"id"`
+	MutatorName  string           `json:"mutatorName"`
+	Replacement  string           `json:"replacement,omitempty"`
+	Location     elementsLocation `json:"location"`
+	Status       string           `json:"status"`
+	StatusReason string           `json:"statusReason,omitempty"`
+	KilledBy     []string         `json:"killedBy,omitempty"`
+	Duration     int64            `json:"duration"`
+}
+
+type elementsLocation struct {
+	Start elementsPosition `json:"start"`
+	End   elementsPosition `json:"end"`
+}
+
+type elementsPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// The thresholds the viewers colour scores with, in percent
+const (
+	elementsHighThreshold = 80
+	elementsLowThreshold  = 60
+)
+
+func elementsStatus(outcome string) string {
+	switch outcome {
+	case outcomeKilled.String():
+		return "Killed"
+	case outcomeSurvived.String():
+		return "Survived"
+	case outcomeTimedOut.String():
+		return "Timeout"
+	case outcomeCrashed.String():
+		return "RuntimeError"
+	case outcomeNotCompiling.String():
+		return "CompileError"
+	case outcomeEquivalent.String():
+		return "Ignored"
+	default:
+		return "Ignored"
+	}
+}
+
+func newElementsReport(config *MutationConfig, report *runReport) *elementsReport {
+	elements := &elementsReport{
+		SchemaVersion: "1",
+		Thresholds:    elementsThresholds{elementsHighThreshold, elementsLowThreshold},
+		ProjectRoot:   config.ProjectRoot,
+		Files:         make(map[string]*elementsFile),
+	}
+
+	sourceLines := make(map[string][]string)
+	for _, mutant := range report.Mutants {
+		file, ok := elements.Files[mutant.File]
+		if !ok {
+			source, err := afero.ReadFile(FS, concatAddingSlashIfNeeded(config.ProjectRoot, mutant.File))
+			if err != nil {
+				log.WithField("file", mutant.File).Debug("Could not read source for mutation-testing-elements report.")
+			}
+
+			file = &elementsFile{Language: "go", Source: string(source), Mutants: []elementsMutant{}}
+			elements.Files[mutant.File] = file
+			sourceLines[mutant.File] = strings.Split(string(source), "\n")
+		}
+
+		file.Mutants = append(file.Mutants, elementsMutant{
+			Id:           mutant.Id,
+			MutatorName:  mutant.Operator,
+			Replacement:  getReplacement(mutant.Diff),
+			Location:     getElementsLocation(mutant, sourceLines[mutant.File]),
+			Status:       elementsStatus(mutant.Outcome),
+			StatusReason: mutant.Outcome,
+			KilledBy:     mutant.KilledBy,
+			Duration:     int64(mutant.DurationSeconds * 1000),
+		})
+	}
+
+	return elements
+}
+
+// The mutation spans from its column to the end of the line, since only the start is known
+// Viewers need a location, so mutants without a position are put at the start of the file
+func getElementsLocation(mutant reportMutant, lines []string) elementsLocation {
+	start := elementsPosition{Line: mutant.Line, Column: mutant.Column}
+	if start.Line < 1 || start.Column < 1 {
+		start = elementsPosition{Line: 1, Column: 1}
+	}
+
+	end := elementsPosition{Line: start.Line, Column: start.Column + 1}
+	if start.Line <= len(lines) && len(lines[start.Line-1])+1 > start.Column {
+		end.Column = len(lines[start.Line-1]) + 1
+	}
+
+	return elementsLocation{start, end}
+}
+
+// The added lines of the diff, which is what the mutated code reads like
+func getReplacement(diff string) string {
+	var added []string
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
+			added = append(added, strings.TrimSpace(line[1:]))
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(added, "\n"))
+}
+
+// Writes the mutants in the mutation-testing-elements schema to the path
+func writeElementsReport(config *MutationConfig, report *runReport) {
+	data, err := json.MarshalIndent(newElementsReport(config, report), "", "  ")
+	if err == nil {
+		err = afero.WriteFile(FS, config.Test.ElementsReport, data, 0644)
+	}
+	if err != nil {
+		log.WithField("path", config.Test.ElementsReport).Error(fmt.Sprintf("Could not write mutation-testing-elements report: %v", err))
+		return
+	}
+
+	log.WithField("path", config.Test.ElementsReport).Info("Wrote mutation-testing-elements report.")
+}
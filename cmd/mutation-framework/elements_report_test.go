@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElementsReport(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	source := "package raft\n\nfunc less(a, b int) bool {\n\treturn a < b\n}\n\nfunc vote() {\n\tif a < b {\n\t}\n}\n"
+	afero.WriteFile(FS, "/project/raft.go", []byte(source), 0644)
+
+	elements := newElementsReport(&MutationConfig{ProjectRoot: "/project/"}, getTestRunReport())
+
+	assert.Equal(t, "1", elements.SchemaVersion)
+	assert.Len(t, elements.Files, 2)
+	assert.Equal(t, "", elements.Files["log.go"].Source)
+
+	raft := elements.Files["raft.go"]
+	assert.Equal(t, source, raft.Source)
+	assert.Equal(t, "go", raft.Language)
+	assert.Len(t, raft.Mutants, 2)
+
+	survived := raft.Mutants[0]
+	assert.Equal(t, "Survived", survived.Status)
+	assert.Equal(t, "branch/if", survived.MutatorName)
+	assert.Equal(t, "if true {", survived.Replacement)
+	assert.Equal(t, int64(2000), survived.Duration)
+	assert.Equal(t, elementsLocation{elementsPosition{7, 5}, elementsPosition{7, 14}}, survived.Location)
+
+	notCompiling := raft.Mutants[1]
+	assert.Equal(t, "CompileError", notCompiling.Status)
+	assert.Equal(t, elementsLocation{elementsPosition{1, 1}, elementsPosition{1, 13}}, notCompiling.Location)
+
+	assert.Equal(t, []string{"TestAppend"}, elements.Files["log.go"].Mutants[0].KilledBy)
+	assert.Equal(t, "Killed", elements.Files["log.go"].Mutants[0].Status)
+}
+
+func TestElementsStatus(t *testing.T) {
+	assert.Equal(t, "Timeout", elementsStatus(outcomeTimedOut.String()))
+	assert.Equal(t, "RuntimeError", elementsStatus(outcomeCrashed.String()))
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Finds mutants whose package compiles to the same code as the original, which no test can kill
+// The assembly the compiler prints is compared, since it leaves out symbol tables and debug info,
+// after dropping the source positions, which differ whenever the mutation moves lines.
+type equivalenceDetector struct {
+	lock sync.Mutex
+	// hash of the compiled original, by package directory relative to the project root
+	originals map[string]string
+}
+
+func newEquivalenceDetector(config *MutationConfig) *equivalenceDetector {
+	if !config.Test.DetectEquivalent {
+		return nil
+	}
+
+	return &equivalenceDetector{originals: make(map[string]string)}
+}
+
+// Source positions like (/home/raft/log.go:42), which are printed for every instruction
+var sourcePositionPattern = regexp.MustCompile(`\([^()\s]+\.go:\d+\)`)
+
+// Strips what differs between the original and a mutant that compile to the same code
+func normalizeAssembly(assembly []byte) []byte {
+	var normalized bytes.Buffer
+
+	scanner := bufio.NewScanner(bytes.NewReader(assembly))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "# ") {
+			// the package header of go build
+			continue
+		}
+
+		normalized.WriteString(sourcePositionPattern.ReplaceAllString(line, "()"))
+		normalized.WriteByte('\n')
+	}
+
+	return normalized.Bytes()
+}
+
+// Compiles the package of the file in the workspace and returns the hash of its normalized assembly
+func compilePackage(workspace *mutantWorkspace, relativeFile string) (string, error) {
+	args := []string{"build", "-o", os.DevNull, "-gcflags", "-S -dwarf=false"}
+	if workspace.overlay != "" {
+		args = append(args, "-overlay", workspace.overlay)
+	}
+	args = append(args, "."+string(os.PathSeparator)+filepath.Dir(relativeFile))
+
+	result, err := workspace.run(workspace.command("go", args...))
+	if err != nil {
+		return "", err
+	}
+	if result.timedOut || result.exitCode != 0 || result.signaled {
+		return "", fmt.Errorf("go build failed with exit code %d", result.exitCode)
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(normalizeAssembly(result.output))), nil
+}
+
+// The hash of the compiled original package of the file, compiled once per package
+func (detector *equivalenceDetector) getOriginal(config *MutationConfig, relativeFile string) (string, error) {
+	pkg := filepath.Dir(relativeFile)
+
+	detector.lock.Lock()
+	defer detector.lock.Unlock()
+
+	if hash, ok := detector.originals[pkg]; ok {
+		return hash, nil
+	}
+
+	workspace, err := newProjectWorkspace(config)
+	if err != nil {
+		return "", err
+	}
+	workspace.timeout = config.Test.getTimeout()
+
+	hash, err := compilePackage(workspace, relativeFile)
+	if err != nil {
+		return "", err
+	}
+
+	detector.originals[pkg] = hash
+	return hash, nil
+}
+
+// Whether the mutant compiles to the same code as the original. Mutants which can't be
+// compiled are not equivalent, the tests will find out that they don't compile.
+func (detector *equivalenceDetector) isEquivalent(config *MutationConfig, mutant MutantInfo) bool {
+	if detector == nil {
+		return false
+	}
+
+	original, err := detector.getOriginal(config, mutant.originalFileRelativePath)
+	if err != nil {
+		log.WithFields(log.Fields{"file": mutant.originalFileRelativePath, "error": err}).
+			Debug("Could not compile original, not checking for equivalence.")
+		return false
+	}
+
+	// a schemata holds all mutants of the file, so only the mutated file itself is compiled
+	var workspace *mutantWorkspace
+	if config.Mutate.Schemata {
+		workspace, err = newOverlayWorkspace(config, mutant)
+	} else {
+		workspace, err = newMutantWorkspace(config, mutant)
+	}
+	if err != nil {
+		return false
+	}
+
+	compiled, err := compilePackage(workspace, mutant.originalFileRelativePath)
+	if err != nil {
+		log.WithFields(log.Fields{"mutant": mutant.mutationFileAbsPath, "error": err}).
+			Debug("Could not compile mutant, not checking for equivalence.")
+		return false
+	}
+
+	return compiled == original
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeAssembly(t *testing.T) {
+	original := []byte("# calc\n" +
+		"calc.Abs STEXT nosplit size=16 args=0x8 locals=0x0 funcflag=0x0\n" +
+		"\t0x0000 00000 (/project/calc/calc.go:3)\tTEXT\tcalc.Abs(SB), NOSPLIT|NOFRAME|ABIInternal, $0-8\n" +
+		"\t0x0000 00000 (/project/calc/calc.go:4)\tTESTQ\tAX, AX\n")
+	moved := []byte("# calc\n" +
+		"calc.Abs STEXT nosplit size=16 args=0x8 locals=0x0 funcflag=0x0\n" +
+		"\t0x0000 00000 (/project/mutants/calc.go.0/calc/calc.go:3)\tTEXT\tcalc.Abs(SB), NOSPLIT|NOFRAME|ABIInternal, $0-8\n" +
+		"\t0x0000 00000 (/project/mutants/calc.go.0/calc/calc.go:5)\tTESTQ\tAX, AX\n")
+	changed := []byte("calc.Abs STEXT nosplit size=16 args=0x8 locals=0x0 funcflag=0x0\n" +
+		"\t0x0000 00000 (/project/calc/calc.go:3)\tTEXT\tcalc.Abs(SB), NOSPLIT|NOFRAME|ABIInternal, $0-8\n" +
+		"\t0x0000 00000 (/project/calc/calc.go:4)\tCMPQ\tAX, $1\n")
+
+	assert.Equal(t, normalizeAssembly(original), normalizeAssembly(moved))
+	assert.NotEqual(t, normalizeAssembly(original), normalizeAssembly(changed))
+	assert.NotContains(t, string(normalizeAssembly(original)), "# calc")
+}
+
+func TestDetectEquivalentMutants(t *testing.T) {
+	config := &MutationConfig{}
+	assert.Nil(t, newEquivalenceDetector(config))
+	var none *equivalenceDetector
+	assert.False(t, none.isEquivalent(config, MutantInfo{}))
+
+	root, err := ioutil.TempDir("", "equivalent")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+
+	source := "package calc\n\nfunc Abs(n int) int {\n\tif n < 0 {\n\t\treturn -n\n\t}\n\treturn n\n}\n"
+	writeFile := func(path string, content string) {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	writeFile(filepath.Join(root, "go.mod"), "module calc\n")
+	writeFile(filepath.Join(root, "calc.go"), source)
+
+	// only the lines move, which changes nothing but the source positions
+	writeFile(filepath.Join(root, "equivalent", "go.mod"), "module calc\n")
+	writeFile(filepath.Join(root, "equivalent", "calc.go"), "package calc\n\n\n"+source[len("package calc\n\n"):])
+	writeFile(filepath.Join(root, "changed", "go.mod"), "module calc\n")
+	writeFile(filepath.Join(root, "changed", "calc.go"), "package calc\n\nfunc Abs(n int) int {\n\tif n > 0 {\n\t\treturn -n\n\t}\n\treturn n\n}\n")
+
+	config.ProjectRoot = root
+	config.Test.DetectEquivalent = true
+	config.Test.Timeout = 60
+	detector := newEquivalenceDetector(config)
+
+	equivalent := MutantInfo{originalFileRelativePath: "calc.go", mutantDirPathAbsPath: filepath.Join(root, "equivalent"),
+		mutationFileAbsPath: filepath.Join(root, "equivalent", "calc.go")}
+	changed := MutantInfo{originalFileRelativePath: "calc.go", mutantDirPathAbsPath: filepath.Join(root, "changed"),
+		mutationFileAbsPath: filepath.Join(root, "changed", "calc.go")}
+
+	assert.True(t, detector.isEquivalent(config, equivalent))
+	assert.False(t, detector.isEquivalent(config, changed))
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/amyjzhu/mutation-framework/faultproxy"
+	log "github.com/sirupsen/logrus"
+)
+
+// Appended to the name of a composition for its run with network faults
+const faultsSuffix = "+faults"
+
+// Whether the mutants are tested with network faults as well
+func (test *Test) usesFaults() bool {
+	return len(test.Faults.Schedule) > 0
+}
+
+func validateFaults(config *MutationConfig) error {
+	faults := config.Test.Faults
+	if len(faults.Links) == 0 && faults.PortOffset == 0 && !config.Test.usesFaults() {
+		return nil
+	}
+
+	if !config.Test.usesCluster() {
+		return fmt.Errorf("faults need a launch command")
+	}
+	if faults.PortOffset != 0 && config.Mutate.Roles.File == "" {
+		return fmt.Errorf("port_offset of faults needs a roles file")
+	}
+	if len(faults.Links) == 0 && faults.PortOffset == 0 {
+		return fmt.Errorf("faults need links or a port_offset")
+	}
+
+	for _, link := range faults.Links {
+		if link.Name == "" || link.Listen == "" || link.Upstream == "" {
+			return fmt.Errorf("every link of faults needs a name, listen and upstream")
+		}
+		if link.Protocol != "" && link.Protocol != "tcp" && link.Protocol != "udp" {
+			return fmt.Errorf("protocol of link %s must be tcp or udp, but is %q", link.Name, link.Protocol)
+		}
+	}
+
+	for _, entry := range faults.Schedule {
+		if entry.StartMs < 0 || entry.DurationMs < 0 || entry.DelayMs < 0 || entry.JitterMs < 0 {
+			return fmt.Errorf("times of faults can't be negative")
+		}
+		for _, probability := range []float64{entry.Drop, entry.Duplicate, entry.Reorder} {
+			if probability < 0 || probability > 1 {
+				return fmt.Errorf("probabilities of faults must be between 0 and 1, but one is %f", probability)
+			}
+		}
+	}
+
+	return nil
+}
+
+// The configured links and one link for every address of the roles file, along with
+// the names of the links of every role
+func getFaultLinks(config *MutationConfig) ([]faultproxy.Link, map[string][]string, error) {
+	var links []faultproxy.Link
+	for _, link := range config.Test.Faults.Links {
+		links = append(links, faultproxy.Link{Name: link.Name, Protocol: link.Protocol,
+			Listen: link.Listen, Upstream: link.Upstream})
+	}
+
+	roleLinks := make(map[string][]string)
+	if config.Test.Faults.PortOffset == 0 {
+		return links, roleLinks, nil
+	}
+
+	roles, err := readRoles(config.Mutate.Roles.File)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, role := range roles.Roles {
+		for i, address := range role.Addresses {
+			port, err := strconv.Atoi(address.Port)
+			if err != nil {
+				return nil, nil, fmt.Errorf("port %q of role %s is not a number", address.Port, role.Name)
+			}
+
+			name := fmt.Sprintf("%s-%d", role.Name, i)
+			links = append(links, faultproxy.Link{Name: name, Protocol: "tcp",
+				Listen:   net.JoinHostPort(address.IP, address.Port),
+				Upstream: net.JoinHostPort(address.IP, strconv.Itoa(port+config.Test.Faults.PortOffset))})
+			roleLinks[role.Name] = append(roleLinks[role.Name], name)
+		}
+	}
+
+	return links, roleLinks, nil
+}
+
+// The schedule of the config, with the roles replaced by their links
+func getFaultSchedule(config *MutationConfig, roleLinks map[string][]string) []faultproxy.Fault {
+	var schedule []faultproxy.Fault
+	for _, entry := range config.Test.Faults.Schedule {
+		var links []string
+		for _, name := range entry.Links {
+			if named, ok := roleLinks[name]; ok {
+				links = append(links, named...)
+			} else {
+				links = append(links, name)
+			}
+		}
+
+		schedule = append(schedule, faultproxy.Fault{
+			Links:     links,
+			Start:     time.Duration(entry.StartMs) * time.Millisecond,
+			Duration:  time.Duration(entry.DurationMs) * time.Millisecond,
+			Delay:     time.Duration(entry.DelayMs) * time.Millisecond,
+			Jitter:    time.Duration(entry.JitterMs) * time.Millisecond,
+			Drop:      entry.Drop,
+			Duplicate: entry.Duplicate,
+			Reorder:   entry.Reorder,
+			Partition: entry.Partition,
+		})
+	}
+
+	return schedule
+}
+
+// Starts the proxies between the nodes, which only forward unless faults is set.
+// Nil if there are no links.
+func startFaultProxy(config *MutationConfig, workspace *mutantWorkspace, faults bool) (*faultproxy.Proxy, error) {
+	links, roleLinks, err := getFaultLinks(config)
+	if err != nil || len(links) == 0 {
+		return nil, err
+	}
+
+	var schedule []faultproxy.Fault
+	if faults {
+		schedule = getFaultSchedule(config, roleLinks)
+	}
+
+	proxy, err := faultproxy.Start(links, schedule, config.Test.Faults.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	workspace.env = setEnv(workspace.env, "MUTATE_FAULTS", fmt.Sprintf("%t", faults))
+	log.WithFields(log.Fields{"links": len(links), "faults": faults}).Debug("Started fault proxy.")
+
+	return proxy, nil
+}
+
+func stopFaultProxy(proxy *faultproxy.Proxy) {
+	if proxy == nil {
+		return
+	}
+
+	proxy.Stop()
+	for name, stats := range proxy.Stats() {
+		log.WithFields(log.Fields{"link": name, "forwarded": stats.Forwarded, "dropped": stats.Dropped,
+			"duplicated": stats.Duplicated, "reordered": stats.Reordered}).Debug("Stopped fault proxy.")
+	}
+}
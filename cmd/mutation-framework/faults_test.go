@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amyjzhu/mutation-framework/faultproxy"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultsConfig(t *testing.T) {
+	config, err := parseAndValidateConfig([]byte(`{"project_root":"home","test":{"nodes":3,"commands":{"launch":"./start.sh"},
+		"faults":{"links":[{"name":"node0","listen":"127.0.0.1:8080","upstream":"127.0.0.1:18080"}],
+			"schedule":[{"links":["node0"],"start_ms":100,"duration_ms":500,"partition":true}]}}}`))
+	assert.Nil(t, err)
+	assert.True(t, config.Test.usesFaults())
+	assert.True(t, config.Test.reportsCompositions())
+
+	// every composition runs without and with faults
+	compositions := config.Test.getCompositions()
+	assert.Len(t, compositions, 2)
+	assert.Equal(t, "first-1", compositions[0].Name)
+	assert.False(t, compositions[0].faults)
+	assert.Equal(t, "first-1+faults", compositions[1].Name)
+	assert.True(t, compositions[1].faults)
+	assert.Equal(t, []int{0}, compositions[1].Nodes)
+
+	for _, invalid := range []string{
+		`{"project_root":"home","test":{"faults":{"links":[{"name":"a","listen":"127.0.0.1:8080","upstream":"127.0.0.1:18080"}]}}}`,
+		`{"project_root":"home","test":{"nodes":3,"commands":{"launch":"./start.sh"},"faults":{"port_offset":10000}}}`,
+		`{"project_root":"home","test":{"nodes":3,"commands":{"launch":"./start.sh"},"faults":{"schedule":[{"partition":true}]}}}`,
+		`{"project_root":"home","test":{"nodes":3,"commands":{"launch":"./start.sh"},"faults":{"links":[{"name":"a","listen":"127.0.0.1:8080"}]}}}`,
+		`{"project_root":"home","test":{"nodes":3,"commands":{"launch":"./start.sh"},"faults":{"links":[{"name":"a","protocol":"sctp","listen":"127.0.0.1:8080","upstream":"127.0.0.1:18080"}]}}}`,
+		`{"project_root":"home","test":{"nodes":3,"commands":{"launch":"./start.sh"},"faults":{"links":[{"name":"a","listen":"127.0.0.1:8080","upstream":"127.0.0.1:18080"}],"schedule":[{"drop":2}]}}}`,
+	} {
+		_, err = parseAndValidateConfig([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestFaultLinksOfRoles(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	afero.WriteFile(FS, "/roles.json", []byte(rolesFile), 0644)
+	config := &MutationConfig{ProjectRoot: "/project/"}
+	config.Mutate.Roles.File = "/roles.json"
+	config.Test.Faults = Faults{
+		Links:      []FaultLink{{Name: "client", Protocol: "udp", Listen: "127.0.0.1:9000", Upstream: "127.0.0.1:19000"}},
+		PortOffset: 10000,
+		Schedule: []FaultEntry{
+			{Links: []string{"leader", "client"}, DelayMs: 20, Drop: 0.5},
+			{StartMs: 1000, Partition: true},
+		},
+	}
+
+	links, roleLinks, err := getFaultLinks(config)
+	assert.Nil(t, err)
+	assert.Equal(t, []faultproxy.Link{
+		{Name: "client", Protocol: "udp", Listen: "127.0.0.1:9000", Upstream: "127.0.0.1:19000"},
+		{Name: "leader-0", Protocol: "tcp", Listen: "127.0.0.1:8080", Upstream: "127.0.0.1:18080"},
+	}, links)
+
+	// roles are replaced by their links
+	assert.Equal(t, []faultproxy.Fault{
+		{Links: []string{"leader-0", "client"}, Delay: 20 * time.Millisecond, Drop: 0.5},
+		{Start: time.Second, Partition: true},
+	}, getFaultSchedule(config, roleLinks))
+}
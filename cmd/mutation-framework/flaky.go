@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Tests that failed in some, but not all, runs of the baseline
+// They are written once by the baseline and only read afterwards
+var flakyTests = make(map[string]struct{})
+
+// Number of times the baseline is run, and the number of runs of a mutant
+// whose verdict is confirmed
+func (test *Test) getRepeat() int {
+	if test.Repeat < 1 {
+		return 1
+	}
+
+	return test.Repeat
+}
+
+// Looks at every run of the baseline. Tests that fail in every run are broken,
+// which is an error; tests that fail only sometimes are flaky.
+func analyzeBaselineRuns(results []*commandResult) (map[string]struct{}, error) {
+	failures := make(map[string]int)
+
+	for _, result := range results {
+		if result.timedOut {
+			return nil, fmt.Errorf("tests timed out on the unmutated project after %s, increase the timeout", result.duration)
+		}
+
+		if result.exitCode == 0 && !result.signaled {
+			continue
+		}
+
+		_, failedTests := readTestResults(result)
+		if len(failedTests) == 0 {
+			return nil, fmt.Errorf("tests fail on the unmutated project (exit code %d), fix them before mutating:\n%s",
+				result.exitCode, string(result.output))
+		}
+
+		for _, test := range failedTests {
+			failures[test]++
+		}
+	}
+
+	flaky := make(map[string]struct{})
+	var broken []string
+	for test, count := range failures {
+		if count == len(results) {
+			broken = append(broken, test)
+		} else {
+			flaky[test] = struct{}{}
+		}
+	}
+
+	if len(broken) > 0 {
+		sort.Strings(broken)
+		return nil, fmt.Errorf("tests fail on the unmutated project, fix them before mutating: %s",
+			strings.Join(broken, ", "))
+	}
+
+	return flaky, nil
+}
+
+// Removes the flaky tests from the failed tests. Also tells whether
+// every failed test was flaky, in which case the failure says nothing.
+func excludeFlakyTests(failedTests []string) (reliable []string, onlyFlaky bool) {
+	reliable = make([]string, 0, len(failedTests))
+	for _, test := range failedTests {
+		if _, flaky := flakyTests[test]; !flaky {
+			reliable = append(reliable, test)
+		}
+	}
+
+	return reliable, len(failedTests) > 0 && len(reliable) == 0
+}
+
+// Re-runs killed and surviving mutants and settles on the outcome most runs agree on
+func confirmVerdict(config *MutationConfig, result *mutantResult) {
+	if result.outcome != outcomeKilled && result.outcome != outcomeSurvived {
+		return
+	}
+
+	reruns := config.Test.getRepeat() - 1
+	if reruns < 1 {
+		reruns = 1
+	}
+
+	for i := 0; i < reruns; i++ {
+		log.WithFields(log.Fields{"mutant": result.mutant.mutationFileAbsPath, "run": i + 2}).
+			Debug("Re-running mutant to confirm the verdict.")
+		result.runs = append(result.runs, runTestsForMutant(config, result.mutant))
+	}
+
+	settleVerdict(result)
+}
+
+// Picks the outcome of most runs, keeping the first verdict on a tie
+func settleVerdict(result *mutantResult) {
+	counts := make(map[mutantOutcome]int)
+	for _, run := range result.runs {
+		counts[run.outcome]++
+	}
+
+	verdict := result.runs[0]
+	for _, run := range result.runs {
+		if counts[run.outcome] > counts[verdict.outcome] {
+			verdict = run
+		}
+	}
+
+	result.outcome = verdict.outcome
+	result.failedTests = verdict.failedTests
+	result.tests = verdict.tests
+	result.duration = verdict.duration
+	result.compositions = verdict.compositions
+}
+
+func printConfidence(results []*mutantResult) {
+	for _, result := range results {
+		log.WithFields(log.Fields{
+			"mutant":     result.mutant.mutationFileAbsPath,
+			"outcome":    result.outcome.String(),
+			"runs":       len(result.runs),
+			"confidence": result.confidence(),
+		}).Info("Verdict confidence.")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func failingRun(tests ...string) *commandResult {
+	output := ""
+	for _, test := range tests {
+		output += "--- FAIL: " + test + " (0.00s)\n"
+	}
+
+	return &commandResult{exitCode: 1, output: []byte(output)}
+}
+
+func TestAnalyzeBaselineRuns(t *testing.T) {
+	passing := &commandResult{exitCode: 0}
+
+	flaky, err := analyzeBaselineRuns([]*commandResult{passing, passing})
+	assert.Nil(t, err)
+	assert.Empty(t, flaky)
+
+	flaky, err = analyzeBaselineRuns([]*commandResult{passing, failingRun("TestElection"), passing})
+	assert.Nil(t, err)
+	assert.Contains(t, flaky, "TestElection")
+
+	_, err = analyzeBaselineRuns([]*commandResult{failingRun("TestElection"), failingRun("TestElection")})
+	assert.NotNil(t, err)
+
+	_, err = analyzeBaselineRuns([]*commandResult{failingRun("TestElection")})
+	assert.NotNil(t, err)
+
+	_, err = analyzeBaselineRuns([]*commandResult{passing, {timedOut: true}})
+	assert.NotNil(t, err)
+}
+
+func TestExcludeFlakyTests(t *testing.T) {
+	flakyTests = map[string]struct{}{"TestElection": {}}
+	defer func() { flakyTests = make(map[string]struct{}) }()
+
+	reliable, onlyFlaky := excludeFlakyTests([]string{"TestElection", "TestPut"})
+	assert.Equal(t, []string{"TestPut"}, reliable)
+	assert.False(t, onlyFlaky)
+
+	reliable, onlyFlaky = excludeFlakyTests([]string{"TestElection"})
+	assert.Empty(t, reliable)
+	assert.True(t, onlyFlaky)
+
+	_, onlyFlaky = excludeFlakyTests(nil)
+	assert.False(t, onlyFlaky)
+}
+
+func TestSettleVerdict(t *testing.T) {
+	result := newMutantResult(MutantInfo{}, &testRun{outcome: outcomeSurvived})
+	result.runs = append(result.runs,
+		&testRun{outcome: outcomeKilled, failedTests: []string{"TestPut"}},
+		&testRun{outcome: outcomeKilled, failedTests: []string{"TestPut"}})
+
+	settleVerdict(result)
+	assert.Equal(t, outcomeKilled, result.outcome)
+	assert.Equal(t, []string{"TestPut"}, result.failedTests)
+	assert.InDelta(t, 2.0/3.0, result.confidence(), 0.0001)
+
+	tie := newMutantResult(MutantInfo{}, &testRun{outcome: outcomeSurvived})
+	tie.runs = append(tie.runs, &testRun{outcome: outcomeKilled})
+
+	settleVerdict(tie)
+	assert.Equal(t, outcomeSurvived, tie.outcome)
+	assert.InDelta(t, 0.5, tie.confidence(), 0.0001)
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// What the HTML report shows of one mutated file
+type htmlFile struct {
+	Name    string
+	Anchor  string
+	Score   *reportScore
+	Lines   []htmlLine
+	Mutants []reportMutant
+}
+
+// One line of an annotated source file
+type htmlLine struct {
+	Number  int
+	Text    string
+	Class   string
+	Mutants []reportMutant
+}
+
+type htmlScoreRow struct {
+	Name   string
+	Anchor string
+	Score  *reportScore
+}
+
+type htmlReport struct {
+	Total     reportScore
+	Files     []htmlScoreRow
+	Operators []htmlScoreRow
+	Sources   []htmlFile
+}
+
+var htmlFunctions = template.FuncMap{
+	"percent": func(score float64) string {
+		return fmt.Sprintf("%.1f%%", score*100)
+	},
+	"outcomeClass": outcomeClass,
+	"diffLines":    diffLines,
+}
+
+type htmlDiffLine struct {
+	Class string
+	Text  string
+}
+
+// Splits a unified diff so that added and removed lines can be coloured
+func diffLines(diff string) []htmlDiffLine {
+	var lines []htmlDiffLine
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		class := ""
+		switch {
+		case strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") || strings.HasPrefix(line, "@@"):
+			class = "header"
+		case strings.HasPrefix(line, "+"):
+			class = "added"
+		case strings.HasPrefix(line, "-"):
+			class = "removed"
+		}
+		lines = append(lines, htmlDiffLine{class, line})
+	}
+
+	return lines
+}
+
+// Survived mutants need attention first, so they are highlighted over the others
+func outcomeClass(outcome string) string {
+	switch outcome {
+	case outcomeSurvived.String():
+		return "survived"
+	case outcomeNotCompiling.String(), outcomeEquivalent.String(), outcomeError.String():
+		return "not-compiling"
+	default:
+		return "killed"
+	}
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(htmlFunctions).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Mutation testing report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table.scores { border-collapse: collapse; margin-bottom: 2em; }
+table.scores td, table.scores th { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: right; }
+table.scores td:first-child, table.scores th:first-child { text-align: left; }
+table.source { border-collapse: collapse; font-family: monospace; width: 100%; }
+table.source td { padding: 0 0.5em; vertical-align: top; white-space: pre; }
+td.number { color: #999; text-align: right; user-select: none; }
+tr.survived { background: #fdd; }
+tr.killed { background: #dfd; }
+tr.not-compiling { background: #eee; }
+div.mutant { white-space: normal; margin: 0.2em 0 0.5em 2em; font-family: sans-serif; }
+span.survived { color: #b00; font-weight: bold; }
+span.killed { color: #070; }
+span.not-compiling { color: #666; }
+pre.diff { background: #f6f6f6; padding: 0.5em; }
+pre.diff .added { color: #070; }
+pre.diff .removed { color: #b00; }
+pre.diff .header { color: #999; }
+</style>
+</head>
+<body>
+<h1>Mutation testing report</h1>
+<p>Mutation score {{percent .Total.Score}} ({{.Total.Killed}} killed, {{.Total.Survived}} survived, {{.Total.TimedOut}} timed out, {{.Total.Crashed}} crashed, {{.Total.NotCompiling}} not compiling, {{.Total.Duplicated}} duplicated, {{.Total.Suppressed}} suppressed, {{.Total.Equivalent}} equivalent, {{.Total.Errors}} errors, total is {{.Total.Total}})</p>
+{{define "diff"}}<pre class="diff">{{range diffLines .}}<span class="{{.Class}}">{{.Text}}</span>
+{{end}}</pre>{{end}}
+{{define "scores"}}<table class="scores">
+<tr><th>Name</th><th>Score</th><th>Killed</th><th>Survived</th><th>Timed out</th><th>Crashed</th><th>Not compiling</th><th>Total</th></tr>
+{{range .}}<tr><td>{{if .Anchor}}<a href="#{{.Anchor}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</td><td>{{percent .Score.Score}}</td><td>{{.Score.Killed}}</td><td>{{.Score.Survived}}</td><td>{{.Score.TimedOut}}</td><td>{{.Score.Crashed}}</td><td>{{.Score.NotCompiling}}</td><td>{{.Score.Total}}</td></tr>
+{{end}}</table>{{end}}
+<h2>Files</h2>
+{{template "scores" .Files}}
+<h2>Operators</h2>
+{{template "scores" .Operators}}
+{{range .Sources}}
+<h2 id="{{.Anchor}}">{{.Name}}</h2>
+<p>Mutation score {{percent .Score.Score}} ({{.Score.Killed}} killed, {{.Score.Survived}} survived, total is {{.Score.Total}})</p>
+{{if .Lines}}<table class="source">
+{{range .Lines}}<tr class="{{.Class}}"><td class="number">{{.Number}}</td><td>{{.Text}}{{range .Mutants}}
+<div class="mutant"><details><summary><span class="{{outcomeClass .Outcome}}">{{.Outcome}}</span> {{.Operator}} ({{.Id}}){{if .KilledBy}}, killed by {{range $i, $test := .KilledBy}}{{if $i}}, {{end}}{{$test}}{{end}}{{end}}</summary>{{template "diff" .Diff}}</details></div>{{end}}</td></tr>
+{{end}}</table>
+{{else}}{{range .Mutants}}<div class="mutant"><details><summary><span class="{{outcomeClass .Outcome}}">{{.Outcome}}</span> {{.Operator}} ({{.Id}})</summary>{{template "diff" .Diff}}</details></div>
+{{end}}{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+// Lays out the report for the template, with the source of every mutated file
+func newHtmlReport(config *MutationConfig, report *runReport) *htmlReport {
+	page := &htmlReport{
+		Total:     report.Total,
+		Files:     sortedScoreRows(report.Files, true),
+		Operators: sortedScoreRows(report.Operators, false),
+	}
+
+	mutantsByFile := make(map[string][]reportMutant)
+	for _, mutant := range report.Mutants {
+		mutantsByFile[mutant.File] = append(mutantsByFile[mutant.File], mutant)
+	}
+
+	for _, row := range page.Files {
+		file := htmlFile{
+			Name:    row.Name,
+			Anchor:  row.Anchor,
+			Score:   row.Score,
+			Mutants: mutantsByFile[row.Name],
+		}
+
+		source, err := afero.ReadFile(FS, concatAddingSlashIfNeeded(config.ProjectRoot, row.Name))
+		if err != nil {
+			log.WithField("file", row.Name).Debug("Could not read source for HTML report, only listing mutants.")
+		} else {
+			file.Lines = annotateSource(source, file.Mutants)
+		}
+
+		page.Sources = append(page.Sources, file)
+	}
+
+	return page
+}
+
+func sortedScoreRows(scores map[string]*reportScore, linked bool) []htmlScoreRow {
+	var rows []htmlScoreRow
+	for name, score := range scores {
+		row := htmlScoreRow{Name: name, Score: score}
+		if linked {
+			row.Anchor = "file-" + strings.NewReplacer("/", "-", ".", "-").Replace(name)
+		}
+		rows = append(rows, row)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Name < rows[j].Name
+	})
+
+	return rows
+}
+
+// Attaches every mutant to the line it mutates and highlights the line
+// by the outcome of its mutants. Mutants without a known line go on the first one.
+func annotateSource(source []byte, mutants []reportMutant) []htmlLine {
+	text := strings.TrimSuffix(string(source), "\n")
+	lines := make([]htmlLine, 0, strings.Count(text, "\n")+1)
+	for i, line := range strings.Split(text, "\n") {
+		lines = append(lines, htmlLine{Number: i + 1, Text: line})
+	}
+
+	for _, mutant := range mutants {
+		index := mutant.Line - 1
+		if index < 0 || index >= len(lines) {
+			index = 0
+		}
+
+		line := &lines[index]
+		line.Mutants = append(line.Mutants, mutant)
+
+		class := outcomeClass(mutant.Outcome)
+		if line.Class == "" || class == "survived" || (class == "killed" && line.Class == "not-compiling") {
+			line.Class = class
+		}
+	}
+
+	return lines
+}
+
+// Writes the HTML report of all mutants to the path
+func writeHtmlReport(config *MutationConfig, report *runReport) {
+	var buf bytes.Buffer
+	err := htmlReportTemplate.Execute(&buf, newHtmlReport(config, report))
+	if err == nil {
+		err = afero.WriteFile(FS, config.Test.HtmlReport, buf.Bytes(), 0644)
+	}
+	if err != nil {
+		log.WithField("path", config.Test.HtmlReport).Error(fmt.Sprintf("Could not write HTML report: %v", err))
+		return
+	}
+
+	log.WithField("path", config.Test.HtmlReport).Info("Wrote HTML report.")
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotateSource(t *testing.T) {
+	source := []byte("package raft\n\nfunc vote() {\n\tvotes++\n}\n")
+	mutants := []reportMutant{
+		{Id: "raft.go.statement-remove.0", Line: 4, Outcome: outcomeKilled.String()},
+		{Id: "raft.go.expression-remove.0", Line: 4, Outcome: outcomeSurvived.String()},
+		{Id: "raft.go.branch-if.0", Line: 3, Outcome: outcomeNotCompiling.String()},
+		{Id: "raft.go.unknown.0", Line: 0, Outcome: outcomeTimedOut.String()},
+	}
+
+	lines := annotateSource(source, mutants)
+	assert.Len(t, lines, 5)
+	assert.Equal(t, "\tvotes++", lines[3].Text)
+	assert.Equal(t, "survived", lines[3].Class)
+	assert.Len(t, lines[3].Mutants, 2)
+	assert.Equal(t, "not-compiling", lines[2].Class)
+	assert.Equal(t, "killed", lines[0].Class)
+	assert.Equal(t, "", lines[1].Class)
+}
+
+func TestHtmlReport(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := &MutationConfig{ProjectRoot: "/project/"}
+	afero.WriteFile(FS, "/project/raft/raft.go", []byte("package raft\n\nvar x = 1 < 2\n"), 0644)
+
+	report := &runReport{
+		Total:     reportScore{Survived: 1, Total: 1},
+		Files:     map[string]*reportScore{"raft/raft.go": {Survived: 1, Total: 1}},
+		Operators: map[string]*reportScore{"expression/comparison": {Survived: 1, Total: 1}},
+		Mutants: []reportMutant{{Id: "raft/raft.go.expression-comparison.0", Operator: "expression/comparison",
+			File: "raft/raft.go", Line: 3, Outcome: outcomeSurvived.String(), Diff: "-var x = 1 < 2\n+var x = 1 <= 2\n"}},
+	}
+
+	var buf bytes.Buffer
+	assert.Nil(t, htmlReportTemplate.Execute(&buf, newHtmlReport(config, report)))
+
+	html := buf.String()
+	assert.Contains(t, html, `<a href="#file-raft-raft-go">raft/raft.go</a>`)
+	assert.Contains(t, html, `<h2 id="file-raft-raft-go">raft/raft.go</h2>`)
+	assert.Contains(t, html, `<tr class="survived"><td class="number">3</td><td>var x = 1 &lt; 2`)
+	assert.Contains(t, html, `<span class="added">&#43;var x = 1 &lt;= 2</span>`)
+	assert.Contains(t, html, "expression/comparison (raft/raft.go.expression-comparison.0)")
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/token"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Lines start..end of a file, both included and counted from 1
+type lineRange struct {
+	start int
+	end   int
+}
+
+func (r lineRange) overlaps(other lineRange) bool {
+	return r.start <= other.end && other.start <= r.end
+}
+
+// Lines of the current files which changed since the revision, by path relative to the project root
+// Uncommitted changes count as well, and every line of a new file that is not tracked yet.
+// Deleted files are left out.
+func getChangedLines(projectRoot string, revision string) (map[string][]lineRange, error) {
+	// the prefixes are set since the user's config may drop or change them, see parseChangedLines
+	output, err := runGit(projectRoot, "diff", "--unified=0", "--no-color", "--no-ext-diff", "--relative",
+		"--src-prefix=a/", "--dst-prefix=b/", revision, "--")
+	if err != nil {
+		return nil, err
+	}
+	changed := parseChangedLines(output)
+
+	// -z since the paths are quoted otherwise if they are unusual
+	output, err = runGit(projectRoot, "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range strings.Split(string(output), "\x00") {
+		if file != "" {
+			changed[filepath.Clean(file)] = []lineRange{{1, math.MaxInt32}}
+		}
+	}
+
+	return changed, nil
+}
+
+func runGit(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// Reads the line ranges of the new files from a diff with --unified=0 and the b/ prefix
+func parseChangedLines(diff []byte) map[string][]lineRange {
+	changed := make(map[string][]lineRange)
+
+	file := ""
+	scanner := bufio.NewScanner(bytes.NewReader(diff))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			file = parseDiffPath(strings.TrimPrefix(line, "+++ "))
+		case strings.HasPrefix(line, "@@") && file != "":
+			if r, ok := parseNewHunkRange(line); ok {
+				changed[file] = append(changed[file], r)
+			}
+		}
+	}
+
+	return changed
+}
+
+// The path of a ---/+++ line without the prefix, empty for /dev/null
+// git quotes paths with unusual characters like "b/caf\303\251.go", and ends paths with spaces with a tab.
+func parseDiffPath(name string) string {
+	name = strings.TrimSuffix(name, "\t")
+	if strings.HasPrefix(name, `"`) {
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			name = unquoted
+		}
+	}
+
+	if name == "/dev/null" {
+		return ""
+	}
+
+	return filepath.Clean(strings.TrimPrefix(name, "b/"))
+}
+
+// Returns the lines of the new file in a hunk header like @@ -12,2 +14,3 @@
+// A hunk which only removes lines gives the lines around the removal.
+func parseNewHunkRange(header string) (lineRange, bool) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return lineRange{}, false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(fields[2], "+"), ",", 2)
+	start, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return lineRange{}, false
+	}
+
+	count := 1
+	if len(parts) == 2 {
+		count, err = strconv.Atoi(parts[1])
+		if err != nil {
+			return lineRange{}, false
+		}
+	}
+
+	if count == 0 {
+		// the lines were removed after line start
+		return lineRange{start, start + 1}, true
+	}
+
+	return lineRange{start, start + count - 1}, true
+}
+
+// Only keeps the files with changes, and returns the changed lines of each of them
+func restrictToChangedFiles(files map[string]string, changed map[string][]lineRange) (map[string]string, map[string][]lineRange) {
+	restricted := make(map[string]string)
+	ranges := make(map[string][]lineRange)
+
+	for relative, absolute := range files {
+		lines, ok := changed[filepath.Clean(relative)]
+		if !ok {
+			log.WithField("file", relative).Debug("File did not change, not mutating it.")
+			continue
+		}
+
+		restricted[relative] = absolute
+		ranges[relative] = lines
+	}
+
+	return restricted, ranges
+}
+
+// With --since, restricts the files to the ones that changed since the revision
+// and remembers their changed lines for mutate
+func restrictToSince(config *MutationConfig, files map[string]string) (map[string]string, error) {
+	if config.Mutate.Since == "" {
+		return files, nil
+	}
+
+	changed, err := getChangedLines(config.ProjectRoot, config.Mutate.Since)
+	if err != nil {
+		return nil, err
+	}
+
+	files, config.Mutate.changedLines = restrictToChangedFiles(files, changed)
+	log.WithFields(log.Fields{"since": config.Mutate.Since, "files": len(files)}).
+		Info("Only mutating lines that changed.")
+
+	return files, nil
+}
+
+// A filter for mutesting.MutateWalkFiltered which accepts the mutations whose changed code
+// overlaps one of the changed lines
+func newChangedLinesFilter(fset *token.FileSet, changedLines []lineRange) func(pos token.Pos, end token.Pos) bool {
+	return func(pos token.Pos, end token.Pos) bool {
+		span := lineRange{fset.Position(pos).Line, fset.Position(end).Line}
+		for _, changed := range changedLines {
+			if span.overlaps(changed) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"go/token"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amyjzhu/mutation-framework"
+	"github.com/amyjzhu/mutation-framework/mutator"
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleGitDiff = `diff --git a/raft/raft.go b/raft/raft.go
+index 1111111..2222222 100644
+--- a/raft/raft.go
++++ b/raft/raft.go
+@@ -10 +10 @@ func (r *raft) vote() {
+-	if r.votes > r.quorum {
++	if r.votes >= r.quorum {
+@@ -20,0 +21,3 @@ func (r *raft) step() {
++	r.term++
++	r.votes = 0
++	r.vote()
+@@ -40,2 +43,0 @@ func (r *raft) stop() {
+-	r.stopped = true
+-	close(r.done)
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,3 +0,0 @@
+-package raft
+`
+
+func TestParseChangedLines(t *testing.T) {
+	changed := parseChangedLines([]byte(sampleGitDiff))
+
+	assert.Equal(t, map[string][]lineRange{
+		"raft/raft.go": {{10, 10}, {21, 23}, {43, 44}},
+	}, changed)
+}
+
+func TestParseChangedLinesWithUnusualPaths(t *testing.T) {
+	diff := "--- \"a/caf\\303\\251.go\"\n+++ \"b/caf\\303\\251.go\"\n@@ -3 +3 @@\n" +
+		"--- a/my file.go\t\n+++ b/my file.go\t\n@@ -5 +5,2 @@\n"
+
+	assert.Equal(t, map[string][]lineRange{
+		"café.go":    {{3, 3}},
+		"my file.go": {{5, 6}},
+	}, parseChangedLines([]byte(diff)))
+}
+
+func TestRestrictToChangedFiles(t *testing.T) {
+	files := map[string]string{"raft/raft.go": "/project/raft/raft.go", "server.go": "/project/server.go"}
+
+	restricted, ranges := restrictToChangedFiles(files, parseChangedLines([]byte(sampleGitDiff)))
+	assert.Equal(t, map[string]string{"raft/raft.go": "/project/raft/raft.go"}, restricted)
+	assert.Len(t, ranges["raft/raft.go"], 3)
+}
+
+func TestChangedLinesFilter(t *testing.T) {
+	// not gofmt'd, the lines of the diff are the ones of the file as it is
+	source := `package raft
+
+
+
+func vote(votes int, quorum int) bool {
+	votes++
+	quorum++
+
+
+	return votes > quorum
+}
+`
+	fset, file, pkg, info := typeCheckSource(t, source)
+
+	getMutatedLines := func(name string, changedLines []lineRange) []int {
+		m, err := mutator.New(name)
+		assert.Nil(t, err)
+
+		var lines []int
+		filter := newChangedLinesFilter(fset, changedLines)
+		changed := mutesting.MutateWalkFiltered(pkg, info, file, m, func(pos token.Pos, end token.Pos) bool {
+			if !filter(pos, end) {
+				return false
+			}
+			lines = append(lines, fset.Position(pos).Line)
+			return true
+		})
+		for {
+			if _, ok := <-changed; !ok {
+				break
+			}
+			changed <- true
+			<-changed
+			changed <- true
+		}
+
+		return lines
+	}
+
+	assert.Equal(t, []int{6, 7}, getMutatedLines("statement/remove", []lineRange{{1, 20}}))
+	assert.Equal(t, []int{7}, getMutatedLines("statement/remove", []lineRange{{7, 10}}))
+	assert.Empty(t, getMutatedLines("statement/remove", []lineRange{{2, 5}}))
+}
+
+// A temporary directory and a function which runs git in it
+func getGitTestDir(t *testing.T) (string, func(args ...string)) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "mutation-testing")
+	assert.Nil(t, err)
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		assert.Nil(t, err, string(output))
+	}
+
+	return dir, git
+}
+
+func TestGetChangedLinesWithUntrackedFiles(t *testing.T) {
+	dir, git := getGitTestDir(t)
+	defer os.RemoveAll(dir)
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "raft.go"), []byte("package raft\n\nvar a = 1\n"), 0644))
+	git("init", "-q")
+	git("add", "raft.go")
+	git("commit", "-q", "-m", "initial")
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "raft.go"), []byte("package raft\n\nvar a = 2\n"), 0644))
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "log"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "log", "log.go"), []byte("package log\n"), 0644))
+
+	changed, err := getChangedLines(dir, "HEAD")
+	assert.Nil(t, err)
+	assert.Equal(t, []lineRange{{3, 3}}, changed["raft.go"])
+	// a new file that was never added counts as changed everywhere
+	assert.Equal(t, []lineRange{{1, math.MaxInt32}}, changed["log/log.go"])
+}
+
+func TestGetChangedLinesWithUnusualPathsAndDiffConfig(t *testing.T) {
+	for _, setting := range []string{"diff.noprefix=true", "diff.mnemonicPrefix=true", "diff.dstPrefix=new/"} {
+		dir, git := getGitTestDir(t)
+
+		for _, file := range []string{"my file.go", "café.go"} {
+			assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, file), []byte("package raft\n\nvar a = 1\n"), 0644))
+		}
+		git("init", "-q")
+		git("config", strings.SplitN(setting, "=", 2)[0], strings.SplitN(setting, "=", 2)[1])
+		git("add", ".")
+		git("commit", "-q", "-m", "initial")
+
+		for _, file := range []string{"my file.go", "café.go"} {
+			assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, file), []byte("package raft\n\nvar a = 2\n"), 0644))
+		}
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "naïve.go"), []byte("package raft\n"), 0644))
+
+		changed, err := getChangedLines(dir, "HEAD")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string][]lineRange{
+			"my file.go": {{3, 3}},
+			"café.go":    {{3, 3}},
+			"naïve.go":   {{1, math.MaxInt32}},
+		}, changed, setting)
+
+		os.RemoveAll(dir)
+	}
+}
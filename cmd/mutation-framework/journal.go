@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// Name of the journal inside the mutant folder
+const journalFile = "journal.jsonl"
+
+// Records the verdict of every executed mutant as soon as it is known,
+// so that an interrupted run can be resumed with --resume
+type runJournal struct {
+	path string
+
+	lock sync.Mutex
+	// the verdicts of the run that is resumed, by mutant name
+	entries map[string]journalEntry
+}
+
+// One line of the journal
+type journalEntry struct {
+	Mutant string `json:"mutant"`
+	// of the mutated file, the verdict does not apply once it changed
+	Checksum        string   `json:"checksum"`
+	Outcome         string   `json:"outcome"`
+	FailedTests     []string `json:"failed_tests,omitempty"`
+	DurationSeconds float64  `json:"duration_seconds"`
+	// the outcome of every run, the first one included
+	Runs []string `json:"runs"`
+	// outcome by composition of the verdict, see Test.Compositions
+	Compositions map[string]string `json:"compositions,omitempty"`
+}
+
+func getJournalPath(config *MutationConfig) string {
+	return appendFolder(getAbsoluteMutationFolderPath(config), journalFile)
+}
+
+// Opens the journal of the run. With resume the verdicts recorded so far are read,
+// otherwise the journal of the previous run is thrown away.
+func openRunJournal(config *MutationConfig) (*runJournal, error) {
+	journal := &runJournal{
+		path:    getJournalPath(config),
+		entries: make(map[string]journalEntry),
+	}
+
+	if !config.Test.Resume {
+		err := FS.Remove(journal.path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		return journal, nil
+	}
+
+	data, err := afero.ReadFile(FS, journal.path)
+	if os.IsNotExist(err) {
+		log.WithField("journal", journal.path).Info("No journal to resume from, executing all mutants.")
+		return journal, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	journal.entries = parseJournal(data)
+	log.WithFields(log.Fields{"journal": journal.path, "verdicts": len(journal.entries)}).Info("Resuming run.")
+
+	return journal, nil
+}
+
+// Reads the lines of a journal. The last line is cut off if the run was killed while writing it,
+// so lines which can't be read are left out.
+func parseJournal(data []byte) map[string]journalEntry {
+	entries := make(map[string]journalEntry)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Mutant == "" {
+			log.WithField("line", scanner.Text()).Warn("Skipping unreadable journal line.")
+			continue
+		}
+		entries[entry.Mutant] = entry
+	}
+
+	return entries
+}
+
+// The verdict recorded for the mutant by the resumed run, if its mutated file is unchanged
+func (journal *runJournal) lookup(config *MutationConfig, mutant MutantInfo) (*mutantResult, bool) {
+	if journal == nil {
+		return nil, false
+	}
+
+	journal.lock.Lock()
+	entry, ok := journal.entries[getMutantName(config, mutant)]
+	journal.lock.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	checksum, err := getChecksum(mutant.mutationFileAbsPath)
+	if err != nil || checksum != entry.Checksum {
+		log.WithField("mutant", mutant.mutationFileAbsPath).Info("Mutant changed since it was recorded, executing it again.")
+		return nil, false
+	}
+
+	return entry.toResult(mutant)
+}
+
+func (entry journalEntry) toResult(mutant MutantInfo) (*mutantResult, bool) {
+	outcome, ok := parseOutcome(entry.Outcome)
+	if !ok {
+		return nil, false
+	}
+	compositions, ok := parseCompositions(entry.Compositions)
+	if !ok {
+		return nil, false
+	}
+
+	result := &mutantResult{
+		mutant:       mutant,
+		outcome:      outcome,
+		failedTests:  entry.FailedTests,
+		duration:     time.Duration(entry.DurationSeconds * float64(time.Second)),
+		compositions: compositions,
+	}
+	for _, name := range entry.Runs {
+		runOutcome, ok := parseOutcome(name)
+		if !ok {
+			return nil, false
+		}
+		result.runs = append(result.runs, &testRun{outcome: runOutcome})
+	}
+
+	return result, true
+}
+
+func newJournalEntry(config *MutationConfig, result *mutantResult) (journalEntry, error) {
+	checksum, err := getChecksum(result.mutant.mutationFileAbsPath)
+	if err != nil {
+		return journalEntry{}, err
+	}
+
+	entry := journalEntry{
+		Mutant:          getMutantName(config, result.mutant),
+		Checksum:        checksum,
+		Outcome:         result.outcome.String(),
+		FailedTests:     result.failedTests,
+		DurationSeconds: result.duration.Seconds(),
+		Compositions:    formatCompositions(result.compositions),
+	}
+	for _, run := range result.runs {
+		entry.Runs = append(entry.Runs, run.outcome.String())
+	}
+
+	return entry, nil
+}
+
+// Appends the verdict of the mutant to the journal and flushes it to disk
+func (journal *runJournal) record(config *MutationConfig, result *mutantResult) {
+	if journal == nil {
+		return
+	}
+
+	entry, err := newJournalEntry(config, result)
+	if err != nil {
+		log.WithFields(log.Fields{"mutant": result.mutant.mutationFileAbsPath, "error": err}).
+			Warn("Could not record mutant in journal.")
+		return
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.WithField("error", err).Warn("Could not record mutant in journal.")
+		return
+	}
+
+	journal.lock.Lock()
+	defer journal.lock.Unlock()
+
+	err = appendLine(journal.path, line)
+	if err != nil {
+		log.WithFields(log.Fields{"journal": journal.path, "error": err}).Warn("Could not record mutant in journal.")
+	}
+}
+
+func appendLine(path string, line []byte) error {
+	file, err := FS.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "%s\n", line)
+	if err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
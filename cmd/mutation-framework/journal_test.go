@@ -0,0 +1,100 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func getJournalTestConfig() *MutationConfig {
+	config := &MutationConfig{ProjectRoot: "/project"}
+	config.Mutate.MutantFolder = "mutants/"
+
+	afero.WriteFile(FS, "/project/mutants/calc.go/branch_if1/calc.go", []byte("package calc // mutated\n"), 0644)
+	afero.WriteFile(FS, "/project/mutants/calc.go/branch_if2/calc.go", []byte("package calc // mutated too\n"), 0644)
+
+	return config
+}
+
+func getJournalTestMutant(name string) MutantInfo {
+	return MutantInfo{
+		originalFileRelativePath: "calc.go",
+		mutantDirPathAbsPath:     "/project/mutants/calc.go/" + name,
+		mutationFileAbsPath:      "/project/mutants/calc.go/" + name + "/calc.go",
+	}
+}
+
+func TestJournalResume(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := getJournalTestConfig()
+	killed := getJournalTestMutant("branch_if1")
+	survived := getJournalTestMutant("branch_if2")
+
+	journal, err := openRunJournal(config)
+	assert.Nil(t, err)
+	journal.record(config, newMutantResult(killed, &testRun{outcome: outcomeKilled, failedTests: []string{"TestAbs"}, duration: time.Second}))
+
+	result := newMutantResult(survived, &testRun{outcome: outcomeSurvived})
+	result.runs = append(result.runs, &testRun{outcome: outcomeKilled})
+	journal.record(config, result)
+
+	config.Test.Resume = true
+	journal, err = openRunJournal(config)
+	assert.Nil(t, err)
+
+	resumed, ok := journal.lookup(config, killed)
+	assert.True(t, ok)
+	assert.Equal(t, outcomeKilled, resumed.outcome)
+	assert.Equal(t, []string{"TestAbs"}, resumed.failedTests)
+	assert.Equal(t, time.Second, resumed.duration)
+
+	resumed, ok = journal.lookup(config, survived)
+	assert.True(t, ok)
+	assert.Equal(t, outcomeSurvived, resumed.outcome)
+	assert.Equal(t, 0.5, resumed.confidence())
+
+	// a changed mutant is executed again
+	afero.WriteFile(FS, killed.mutationFileAbsPath, []byte("package calc // mutated differently\n"), 0644)
+	_, ok = journal.lookup(config, killed)
+	assert.False(t, ok)
+
+	// without resume the journal starts over
+	config.Test.Resume = false
+	journal, err = openRunJournal(config)
+	assert.Nil(t, err)
+	_, ok = journal.lookup(config, survived)
+	assert.False(t, ok)
+	exists, _ := afero.Exists(FS, getJournalPath(config))
+	assert.False(t, exists)
+}
+
+func TestParseJournalSkipsCutOffLine(t *testing.T) {
+	data := []byte(`{"mutant":"calc.go/branch_if1","checksum":"abc","outcome":"killed","duration_seconds":1,"runs":["killed"]}
+
+{"mutant":"calc.go/branch_if2","checksum":"de`)
+
+	entries := parseJournal(data)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "killed", entries["calc.go/branch_if1"].Outcome)
+}
+
+func TestJournalWithoutFile(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := getJournalTestConfig()
+	config.Test.Resume = true
+
+	journal, err := openRunJournal(config)
+	assert.Nil(t, err)
+	_, ok := journal.lookup(config, getJournalTestMutant("branch_if1"))
+	assert.False(t, ok)
+
+	var disabled *runJournal
+	_, ok = disabled.lookup(config, getJournalTestMutant("branch_if1"))
+	assert.False(t, ok)
+}
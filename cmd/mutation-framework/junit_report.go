@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// JUnit XML as CI servers read it, with one test suite per mutated file
+// and one test case per mutant. Surviving mutants are failing test cases.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Content string `xml:",chardata"`
+}
+
+func junitSeconds(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
+
+func newJunitReport(report *runReport) *junitTestSuites {
+	suites := &junitTestSuites{Name: "mutation testing"}
+	var totalTime float64
+
+	byFile := make(map[string]*junitTestSuite)
+	suiteTimes := make(map[string]float64)
+	var files []string
+	for _, mutant := range report.Mutants {
+		suite, ok := byFile[mutant.File]
+		if !ok {
+			suite = &junitTestSuite{Name: mutant.File}
+			byFile[mutant.File] = suite
+			files = append(files, mutant.File)
+		}
+
+		testCase := junitTestCase{
+			Name:      fmt.Sprintf("%s (%s at line %d)", mutant.Id, mutant.Operator, mutant.Line),
+			ClassName: mutant.File,
+			Time:      junitSeconds(mutant.DurationSeconds),
+		}
+
+		switch mutant.Outcome {
+		case outcomeSurvived.String():
+			testCase.Failure = &junitMessage{
+				Message: fmt.Sprintf("mutant survived: %s at line %d, column %d", mutant.Operator, mutant.Line, mutant.Column),
+				Content: mutant.Diff,
+			}
+			suite.Failures++
+		case outcomeNotCompiling.String():
+			testCase.Skipped = &junitMessage{Message: "mutant does not compile"}
+			suite.Skipped++
+		case outcomeEquivalent.String():
+			testCase.Skipped = &junitMessage{Message: "mutant is equivalent to the original"}
+			suite.Skipped++
+		case outcomeError.String():
+			testCase.Skipped = &junitMessage{Message: "tests could not be run against the mutant"}
+			suite.Skipped++
+		default:
+			testCase.SystemOut = fmt.Sprintf("mutant %s, failing tests: %s", mutant.Outcome, strings.Join(mutant.KilledBy, ", "))
+		}
+
+		suite.Tests++
+		suite.Cases = append(suite.Cases, testCase)
+		suiteTimes[mutant.File] += mutant.DurationSeconds
+	}
+
+	sort.Strings(files)
+	for _, file := range files {
+		suite := byFile[file]
+		suite.Time = junitSeconds(suiteTimes[file])
+
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Skipped += suite.Skipped
+		totalTime += suiteTimes[file]
+		suites.Suites = append(suites.Suites, *suite)
+	}
+	suites.Time = junitSeconds(totalTime)
+
+	return suites
+}
+
+// Writes the mutants as JUnit XML to the path
+func writeJunitReport(config *MutationConfig, report *runReport) {
+	data, err := xml.MarshalIndent(newJunitReport(report), "", "  ")
+	if err == nil {
+		data = append([]byte(xml.Header), data...)
+		err = afero.WriteFile(FS, config.Test.JunitReport, data, 0644)
+	}
+	if err != nil {
+		log.WithField("path", config.Test.JunitReport).Error(fmt.Sprintf("Could not write JUnit report: %v", err))
+		return
+	}
+
+	log.WithField("path", config.Test.JunitReport).Info("Wrote JUnit report.")
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getTestRunReport() *runReport {
+	return &runReport{
+		Mutants: []reportMutant{
+			{Id: "log.go.branch-if.0", Operator: "branch/if", File: "log.go", Line: 3, Column: 2,
+				Outcome: outcomeKilled.String(), DurationSeconds: 1.5, KilledBy: []string{"TestAppend"}},
+			{Id: "raft.go.branch-if.0", Operator: "branch/if", File: "raft.go", Line: 7, Column: 5,
+				Outcome: outcomeSurvived.String(), DurationSeconds: 2, Diff: "-\tif a < b {\n+\tif true {\n"},
+			{Id: "raft.go.statement-remove.0", Operator: "statement/remove", File: "raft.go",
+				Outcome: outcomeNotCompiling.String()},
+		},
+	}
+}
+
+func TestJunitReport(t *testing.T) {
+	junit := newJunitReport(getTestRunReport())
+
+	assert.Equal(t, 3, junit.Tests)
+	assert.Equal(t, 1, junit.Failures)
+	assert.Equal(t, 1, junit.Skipped)
+	assert.Equal(t, "3.500", junit.Time)
+	assert.Len(t, junit.Suites, 2)
+
+	raft := junit.Suites[1]
+	assert.Equal(t, "raft.go", raft.Name)
+	assert.Equal(t, "raft.go.branch-if.0 (branch/if at line 7)", raft.Cases[0].Name)
+	assert.Equal(t, "mutant survived: branch/if at line 7, column 5", raft.Cases[0].Failure.Message)
+	assert.Equal(t, "-\tif a < b {\n+\tif true {\n", raft.Cases[0].Failure.Content)
+	assert.NotNil(t, raft.Cases[1].Skipped)
+	assert.Nil(t, junit.Suites[0].Cases[0].Failure)
+
+	data, err := xml.Marshal(junit)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `<testsuites name="mutation testing" tests="3" failures="1" skipped="1" time="3.500">`)
+	assert.Contains(t, string(data), `<failure message="mutant survived: branch/if at line 7, column 5">`)
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// Records which tests kill which mutants
+// Mutants are named by their folder inside the mutant folder, e.g. nsqd/nsqd.go.branch-if.1
+type killMatrix struct {
+	mutants  []string
+	outcomes map[string]mutantOutcome
+	// every test that was seen, including the ones that never failed
+	tests []string
+	kills map[string]map[string]struct{}
+	// the longest a test took against any mutant, only known with go test -json
+	durations map[string]time.Duration
+}
+
+// A mutant that is killed by every test which kills a mutant that is harder to kill
+type subsumedMutant struct {
+	mutant     string
+	subsumedBy string
+}
+
+func newKillMatrix(config *MutationConfig, results []*mutantResult) *killMatrix {
+	matrix := &killMatrix{
+		outcomes:  make(map[string]mutantOutcome),
+		kills:     make(map[string]map[string]struct{}),
+		durations: make(map[string]time.Duration),
+	}
+
+	tests := make(map[string]struct{})
+	for _, result := range results {
+		mutant := getMutantName(config, result.mutant)
+		matrix.mutants = append(matrix.mutants, mutant)
+		matrix.outcomes[mutant] = result.outcome
+		matrix.kills[mutant] = make(map[string]struct{})
+
+		for _, run := range result.runs {
+			for _, test := range run.tests {
+				tests[test.id()] = struct{}{}
+				if test.elapsed > matrix.durations[test.id()] {
+					matrix.durations[test.id()] = test.elapsed
+				}
+			}
+		}
+
+		if !result.outcome.isDetected() {
+			continue
+		}
+
+		for _, test := range result.failedTests {
+			tests[test] = struct{}{}
+			matrix.kills[mutant][test] = struct{}{}
+		}
+	}
+
+	sort.Strings(matrix.mutants)
+	matrix.tests = testNames(tests)
+	sort.Strings(matrix.tests)
+
+	return matrix
+}
+
+// Returns the name of the mutant folder relative to the mutant folder of the config
+func getMutantName(config *MutationConfig, mutant MutantInfo) string {
+	name, err := filepath.Rel(getAbsoluteMutationFolderPath(config), mutant.mutantDirPathAbsPath)
+	if err != nil {
+		return mutant.mutantDirPathAbsPath
+	}
+
+	return filepath.ToSlash(name)
+}
+
+func (matrix *killMatrix) isKilledBy(mutant string, test string) bool {
+	_, ok := matrix.kills[mutant][test]
+	return ok
+}
+
+// Picks tests greedily until they kill every mutant that any test kills
+// Of the tests that kill as many new mutants, the fastest one is picked
+func (matrix *killMatrix) minimalTestSet() []string {
+	remaining := make(map[string]struct{})
+	for mutant, tests := range matrix.kills {
+		if len(tests) > 0 {
+			remaining[mutant] = struct{}{}
+		}
+	}
+
+	var minimal []string
+	picked := make(map[string]struct{})
+	for len(remaining) > 0 {
+		best, bestKills := "", 0
+		for _, test := range matrix.tests {
+			if _, ok := picked[test]; ok {
+				continue
+			}
+
+			kills := 0
+			for mutant := range remaining {
+				if matrix.isKilledBy(mutant, test) {
+					kills++
+				}
+			}
+
+			if kills > bestKills || (kills == bestKills && kills > 0 && matrix.durations[test] < matrix.durations[best]) {
+				best, bestKills = test, kills
+			}
+		}
+
+		if bestKills == 0 {
+			break
+		}
+
+		picked[best] = struct{}{}
+		minimal = append(minimal, best)
+		for mutant := range remaining {
+			if matrix.isKilledBy(mutant, best) {
+				delete(remaining, mutant)
+			}
+		}
+	}
+
+	sort.Strings(minimal)
+	return minimal
+}
+
+// Tests that did not kill a single mutant, neither by themselves nor through a subtest
+func (matrix *killMatrix) testsKillingNothing() []string {
+	killing := make(map[string]struct{})
+	for _, tests := range matrix.kills {
+		for test := range tests {
+			killing[test] = struct{}{}
+		}
+	}
+
+	var useless []string
+	for _, test := range matrix.tests {
+		if _, ok := killing[test]; ok || hasFailedSubtest(test, killing) {
+			continue
+		}
+		useless = append(useless, test)
+	}
+
+	return useless
+}
+
+// Groups of killed mutants which are killed by exactly the same tests
+// Only the first mutant of each group is needed to judge the tests
+func (matrix *killMatrix) duplicateMutants() [][]string {
+	groups := make(map[string][]string)
+	var keys []string
+	for _, mutant := range matrix.mutants {
+		if len(matrix.kills[mutant]) == 0 {
+			continue
+		}
+
+		key := getTestKey(testNames(matrix.kills[mutant]))
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], mutant)
+	}
+
+	var duplicates [][]string
+	for _, key := range keys {
+		if len(groups[key]) > 1 {
+			duplicates = append(duplicates, groups[key])
+		}
+	}
+
+	return duplicates
+}
+
+// Killed mutants whose killing tests are a strict superset of those of another mutant
+// Any test suite that kills the other mutant kills these as well, so they are redundant
+func (matrix *killMatrix) subsumedMutants() []subsumedMutant {
+	var subsumed []subsumedMutant
+	for _, mutant := range matrix.mutants {
+		kills := matrix.kills[mutant]
+		if len(kills) == 0 {
+			continue
+		}
+
+		// prefer the mutant with the fewest killing tests, it is the hardest one to kill
+		subsumedBy := ""
+		for _, other := range matrix.mutants {
+			otherKills := matrix.kills[other]
+			if len(otherKills) == 0 || len(otherKills) >= len(kills) || !isSubset(otherKills, kills) {
+				continue
+			}
+			if subsumedBy == "" || len(otherKills) < len(matrix.kills[subsumedBy]) {
+				subsumedBy = other
+			}
+		}
+
+		if subsumedBy != "" {
+			subsumed = append(subsumed, subsumedMutant{mutant, subsumedBy})
+		}
+	}
+
+	return subsumed
+}
+
+func isSubset(subset map[string]struct{}, set map[string]struct{}) bool {
+	for element := range subset {
+		if _, ok := set[element]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Logs what the kill matrix says about the test suite
+func (matrix *killMatrix) printSummary() {
+	minimal := matrix.minimalTestSet()
+	log.WithFields(log.Fields{"tests": getTestKey(minimal), "count": len(minimal), "of": len(matrix.tests)}).
+		Info("These tests kill every mutant that the whole test suite kills.")
+
+	useless := matrix.testsKillingNothing()
+	if len(useless) > 0 {
+		log.WithField("tests", getTestKey(useless)).Info("Tests that did not kill any mutant.")
+	}
+
+	for _, group := range matrix.duplicateMutants() {
+		log.WithField("mutants", group).Info("Mutants killed by the same tests.")
+	}
+
+	for _, subsumed := range matrix.subsumedMutants() {
+		log.WithFields(log.Fields{"mutant": subsumed.mutant, "subsumed_by": subsumed.subsumedBy}).
+			Debug("Mutant is subsumed by another mutant.")
+	}
+}
+
+// Writes the kill matrix to the path, as CSV if it ends with .csv and as JSON otherwise
+func (matrix *killMatrix) write(path string) error {
+	var data []byte
+	var err error
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		data, err = matrix.toCsv()
+	} else {
+		data, err = matrix.toJson()
+	}
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(FS, path, data, 0644)
+}
+
+// One row per mutant, one column per test, 1 where the test kills the mutant
+func (matrix *killMatrix) toCsv() ([]byte, error) {
+	var builder strings.Builder
+	writer := csv.NewWriter(&builder)
+
+	header := append([]string{"mutant", "outcome"}, matrix.tests...)
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+
+	for _, mutant := range matrix.mutants {
+		row := []string{mutant, matrix.outcomes[mutant].String()}
+		for _, test := range matrix.tests {
+			if matrix.isKilledBy(mutant, test) {
+				row = append(row, "1")
+			} else {
+				row = append(row, "0")
+			}
+		}
+
+		if err := writer.Write(row); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	return []byte(builder.String()), writer.Error()
+}
+
+type killMatrixJson struct {
+	Tests               []killMatrixTestJson   `json:"tests"`
+	Mutants             []killMatrixMutantJson `json:"mutants"`
+	MinimalTests        []string               `json:"minimal_tests"`
+	TestsKillingNothing []string               `json:"tests_killing_nothing"`
+	DuplicateMutants    [][]string             `json:"duplicate_mutants"`
+	SubsumedMutants     []subsumedMutantJson   `json:"subsumed_mutants"`
+}
+
+type killMatrixTestJson struct {
+	Name string `json:"name"`
+	// the longest the test took, 0 if unknown
+	DurationSeconds float64 `json:"duration_seconds"`
+	Kills           int     `json:"kills"`
+}
+
+type killMatrixMutantJson struct {
+	Mutant   string   `json:"mutant"`
+	Outcome  string   `json:"outcome"`
+	KilledBy []string `json:"killed_by"`
+}
+
+type subsumedMutantJson struct {
+	Mutant     string `json:"mutant"`
+	SubsumedBy string `json:"subsumed_by"`
+}
+
+func (matrix *killMatrix) toJson() ([]byte, error) {
+	output := killMatrixJson{
+		Tests:               []killMatrixTestJson{},
+		Mutants:             []killMatrixMutantJson{},
+		MinimalTests:        nonNil(matrix.minimalTestSet()),
+		TestsKillingNothing: nonNil(matrix.testsKillingNothing()),
+		DuplicateMutants:    [][]string{},
+		SubsumedMutants:     []subsumedMutantJson{},
+	}
+
+	kills := make(map[string]int)
+	for _, mutant := range matrix.mutants {
+		killedBy := testNames(matrix.kills[mutant])
+		sort.Strings(killedBy)
+		for _, test := range killedBy {
+			kills[test]++
+		}
+
+		output.Mutants = append(output.Mutants,
+			killMatrixMutantJson{mutant, matrix.outcomes[mutant].String(), killedBy})
+	}
+
+	for _, test := range matrix.tests {
+		output.Tests = append(output.Tests,
+			killMatrixTestJson{test, matrix.durations[test].Seconds(), kills[test]})
+	}
+
+	output.DuplicateMutants = append(output.DuplicateMutants, matrix.duplicateMutants()...)
+	for _, subsumed := range matrix.subsumedMutants() {
+		output.SubsumedMutants = append(output.SubsumedMutants, subsumedMutantJson{subsumed.mutant, subsumed.subsumedBy})
+	}
+
+	return json.MarshalIndent(output, "", "  ")
+}
+
+// Empty lists are written as [] instead of null
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+
+	return list
+}
+
+// Builds the kill matrix of all mutants, logs what it says and exports it if configured
+func reportKillMatrix(config *MutationConfig, results []*mutantResult) {
+	matrix := newKillMatrix(config, results)
+	matrix.printSummary()
+
+	if config.Test.KillMatrix == "" {
+		return
+	}
+
+	err := matrix.write(config.Test.KillMatrix)
+	if err != nil {
+		log.WithField("path", config.Test.KillMatrix).Error(fmt.Sprintf("Could not write kill matrix: %v", err))
+		return
+	}
+
+	log.WithField("path", config.Test.KillMatrix).Info("Wrote kill matrix.")
+}
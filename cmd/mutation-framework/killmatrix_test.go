@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func killMatrixResult(name string, outcome mutantOutcome, failedTests ...string) *mutantResult {
+	mutant := MutantInfo{mutantDirPathAbsPath: "/project/mutants/" + name}
+	run := &testRun{outcome: outcome, failedTests: failedTests}
+	for _, test := range []string{"TestAppend", "TestElection", "TestSlow", "TestUseless"} {
+		elapsed := time.Second
+		if test == "TestSlow" {
+			elapsed = time.Minute
+		}
+		run.tests = append(run.tests, &testResult{name: test, elapsed: elapsed})
+	}
+
+	return newMutantResult(mutant, run)
+}
+
+func getTestKillMatrix() *killMatrix {
+	config := &MutationConfig{ProjectRoot: "/project/", Mutate: Mutate{MutantFolder: "mutants/"}}
+
+	return newKillMatrix(config, []*mutantResult{
+		killMatrixResult("raft.go.branch-if.1", outcomeKilled, "TestElection"),
+		killMatrixResult("raft.go.branch-if.2", outcomeKilled, "TestElection", "TestSlow"),
+		killMatrixResult("raft.go.branch-if.3", outcomeTimedOut, "TestElection", "TestSlow"),
+		killMatrixResult("log.go.remove-statement.1", outcomeKilled, "TestAppend", "TestSlow"),
+		killMatrixResult("log.go.remove-statement.2", outcomeSurvived),
+	})
+}
+
+func TestKillMatrix(t *testing.T) {
+	matrix := getTestKillMatrix()
+
+	assert.Equal(t, []string{"TestAppend", "TestElection", "TestSlow", "TestUseless"}, matrix.tests)
+	assert.True(t, matrix.isKilledBy("raft.go.branch-if.3", "TestSlow"))
+	assert.False(t, matrix.isKilledBy("log.go.remove-statement.2", "TestSlow"))
+
+	// TestSlow kills as many mutants as TestElection, but the faster tests are enough
+	assert.Equal(t, []string{"TestAppend", "TestElection"}, matrix.minimalTestSet())
+	assert.Equal(t, []string{"TestUseless"}, matrix.testsKillingNothing())
+	assert.Equal(t, [][]string{{"raft.go.branch-if.2", "raft.go.branch-if.3"}}, matrix.duplicateMutants())
+	assert.Equal(t, []subsumedMutant{
+		{"raft.go.branch-if.2", "raft.go.branch-if.1"},
+		{"raft.go.branch-if.3", "raft.go.branch-if.1"},
+	}, matrix.subsumedMutants())
+}
+
+func TestKillMatrixSubtests(t *testing.T) {
+	matrix := &killMatrix{
+		mutants: []string{"raft.go.branch-if.1"},
+		tests:   []string{"TestElection", "TestElection/three_nodes"},
+		kills:   map[string]map[string]struct{}{"raft.go.branch-if.1": {"TestElection/three_nodes": {}}},
+	}
+
+	assert.Empty(t, matrix.testsKillingNothing())
+}
+
+func TestKillMatrixToCsv(t *testing.T) {
+	data, err := getTestKillMatrix().toCsv()
+	assert.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Equal(t, "mutant,outcome,TestAppend,TestElection,TestSlow,TestUseless", lines[0])
+	assert.Equal(t, "log.go.remove-statement.1,killed,1,0,1,0", lines[1])
+	assert.Equal(t, "log.go.remove-statement.2,survived,0,0,0,0", lines[2])
+	assert.Equal(t, "raft.go.branch-if.3,timed out,0,1,1,0", lines[5])
+}
+
+func TestKillMatrixToJson(t *testing.T) {
+	data, err := getTestKillMatrix().toJson()
+	assert.Nil(t, err)
+
+	var output killMatrixJson
+	assert.Nil(t, json.Unmarshal(data, &output))
+	assert.Len(t, output.Mutants, 5)
+	assert.Equal(t, killMatrixTestJson{"TestSlow", 60, 3}, output.Tests[2])
+	assert.Equal(t, []string{"TestAppend", "TestElection"}, output.MinimalTests)
+	assert.Equal(t, []string{}, output.Mutants[1].KilledBy)
+}
+
+func TestPutFailedTestsInMap(t *testing.T) {
+	testsToMutants = make(map[string][]string)
+	defer func() { testsToMutants = make(map[string][]string) }()
+
+	putFailedTestsInMap("first", []string{"TestB", "TestA"})
+	putFailedTestsInMap("second", []string{"TestA", "TestB"})
+	putFailedTestsInMap("survivor", nil)
+
+	assert.Equal(t, map[string][]string{"TestA, TestB": {"first", "second"}}, testsToMutants)
+}
@@ -20,6 +20,8 @@ const (
 	returnHelp
 	returnBashCompletion
 	returnError
+	// the mutation score or the number of survivors missed a threshold
+	returnThresholdFailed
 )
 
 const (
@@ -35,35 +37,63 @@ type Args struct {
 		Debug                bool `long:"debug" description:"Debug log output"`
 		Help                 bool `long:"help" description:"Show this help message"`
 		Verbose              bool `long:"verbose" description:"Verbose log output"`
-		ConfigPath 		string `long:"config" descriptionL:"Path to mutation config file" required:"true"`
+		ConfigPath 		string `long:"config" descriptionL:"Path to mutation config file"`
 		ListMutators    bool     `long:"list-mutators" description:"List all available mutators"`
 		Json bool `long:"json-output" description:"Log events in json format"`
 	} `group:"General Args"`
 
 	/*
 	Files struct {
-		Blacklist []string `long:"blacklist" description:"List of MD5 checksums of mutations which should be ignored. Each checksum must end with a new line character."`
 		ListFiles bool     `long:"list-files" description:"List found files"`
 	} `group:"File Args"`
 */
 
 	Exec struct {
 		Composition int `long:"composition" description:"Describe how many nodes should contain the mutation"`
+		Nodes       int `long:"nodes" description:"Number of nodes the launch command starts for each mutant"`
 		MutateOnly bool   `long:"no-exec" description:"Skip the built-in exec command and just generate the mutations"`
-		Timeout    uint   `long:"exec-timeout" description:"Sets a timeout for the command execution (in seconds)" default:"10"`
+		Timeout    uint   `long:"exec-timeout" description:"Sets a timeout for the command execution (in seconds, default 10)"`
 		ExecOnly   bool   `long:"no-mutate" description:"Does not mutate the files, only executes existing mutations"`
 		CustomTest string   `string:"custom-test" description:"Specifies location of test script"`
 		Overwrite bool `long:"overwrite" description:"True if want to overwrite existing mutants in name clash"`
+		Workers    int    `long:"workers" description:"Number of mutants to execute in parallel"`
+		Since      string `long:"since" description:"Only mutates lines which changed since this git revision"`
+		Overlay    bool   `long:"overlay" description:"Stores only the mutated file of each mutant and tests it with go test -overlay"`
+		Workspace  string `long:"workspace" description:"How mutants get the unchanged files of the project: copy, hardlink or reflink"`
+		Roles      string   `long:"roles" description:"Node roles file, only the lines of its roles are mutated"`
+		Role       []string `long:"role" description:"Mutates only the lines of this role of the node roles file, may be repeated"`
+		Suppressions string `long:"suppressions" description:"File of mutants which are not created, by checksum or key, each with a reason"`
+		Schemata bool `long:"schemata" description:"Weaves the mutants of each file into one source which is compiled once, the active mutant is chosen at runtime"`
+		DetectEquivalent bool `long:"detect-equivalent" description:"Compiles every mutant first and does not test mutants which compile to the same code as the original"`
+		KillMatrix string `long:"kill-matrix" description:"Writes which tests kill which mutants to this file (.csv or .json)"`
+		Report     string `long:"report" description:"Writes the results of all mutants to this JSON file"`
+		HtmlReport string `long:"html-report" description:"Writes an HTML report with the annotated source of mutated files to this file"`
+		JunitReport string `long:"junit-report" description:"Writes the mutants as JUnit XML to this file, survivors are failing test cases"`
+		ElementsReport string `long:"elements-report" description:"Writes the mutants in the mutation-testing-elements JSON schema to this file"`
+		MinScore   *float64 `long:"min-score" description:"Exits with code 4 if the mutation score of all files is lower (between 0 and 1)"`
+		MaxSurvivors *int   `long:"max-survivors" description:"Exits with code 4 if more mutants survive"`
+		Cache      string `long:"cache" description:"Keeps verdicts in this file and skips mutants whose verdict can't have changed"`
+		Resume     bool   `long:"resume" description:"Continues an interrupted run, skipping the mutants its journal has verdicts for"`
 	} `group:"Exec Args"`
 }
 
+// passed are the killed mutants, failed the ones that survived, skipped the ones that didn't compile
 type mutationStats struct {
 	passed     int
 	failed     int
 	duplicated int
 	skipped    int
+	timedOut   int
+	crashed    int
+	// left out because of the suppression file, like duplicates they don't count towards the total
+	suppressed int
+	// compile to the same code as the original, they don't count towards the total either
+	equivalent int
+	// could not be tested because of a problem of the framework or the cluster, not counted either
+	errors int
 }
 
+// Mutants that time out or crash the tests count as detected
 func (ms *mutationStats) Score() float64 {
 	total := ms.Total()
 
@@ -71,16 +101,17 @@ func (ms *mutationStats) Score() float64 {
 		return 0.0
 	}
 
-	return float64(ms.passed) / float64(total)
+	return float64(ms.passed+ms.timedOut+ms.crashed) / float64(total)
 }
 
 func (ms *mutationStats) Total() int {
-	return ms.passed + ms.failed + ms.skipped
+	return ms.passed + ms.failed + ms.skipped + ms.timedOut + ms.crashed
 }
 
 func mainCmd(args []string) (exitCode int) {
 	config, files, exitCode := initializeExecution(args)
-	if exitCode != returnOk {
+	// there is no config if the arguments asked for something else, e.g. compare
+	if exitCode != returnOk || config == nil {
 		return
 	}
 
@@ -96,15 +127,16 @@ func initializeExecution(args []string) (*MutationConfig, map[string]string, int
 	}
 
 	// Parse config options
-	pathToConfig := opts.General.ConfigPath
-	config, err := getConfig(pathToConfig)
+	config, err := loadConfig(opts)
 	if err != nil {
-		exitError(err.Error())
+		return nil, nil, exitError(err.Error())
 	}
 
-	consolidateArgsIntoConfig(opts, config)
 	setUpLogging(config)
-	files := config.getRelativeAndAbsoluteFiles()
+	files, err := restrictToSince(config, config.getRelativeAndAbsoluteFiles())
+	if err != nil {
+		return nil, nil, exitError(err.Error())
+	}
 
 	return config, files, returnOk
 }
@@ -114,7 +146,26 @@ func performMutationTesting(config *MutationConfig, files map[string]string) (ex
 	var stats map[string]*mutationStats
 	var err error
 
+	if config.Mutate.Since != "" && len(files) == 0 {
+		log.WithField("since", config.Mutate.Since).Info("None of the files changed, nothing to do.")
+		return returnOk
+	}
+
+	if !config.Test.Disable {
+		_, err = runBaseline(config, files)
+		if err != nil {
+			log.WithError(err).Error("Baseline failed, not mutating.")
+			return returnError
+		}
+	}
+
 	if !config.Mutate.Disable {
+		if config.Test.Resume && !config.Mutate.Overwrite {
+			// the mutants of the interrupted run may be half written or changed by their tests
+			log.Info("Resuming, so the mutants of the interrupted run are created again.")
+			config.Mutate.Overwrite = true
+		}
+
 		stats, mutantPaths, exitCode = mutateFiles(config, files)
 		if exitCode == returnError {
 			return exitCode
@@ -122,7 +173,7 @@ func performMutationTesting(config *MutationConfig, files map[string]string) (ex
 	} else {
 		stats = make(map[string]*mutationStats)
 		log.Info("Running tests without mutating.")
-		mutantPaths, err = findAllMutantsInFolder(config, stats, files)
+		mutantPaths, err = findMutants(config, stats, files)
 		if err != nil {
 			log.Error(err)
 			return returnError
@@ -168,6 +219,7 @@ func checkArguments(args []string, opts *Args) (bool, int) {
 	p := flags.NewNamedParser("mutation-framework", flags.None)
 
 	p.ShortDescription = "Mutation testing for Go source code"
+	p.Usage = "[OPTIONS] | compare OLD_REPORT NEW_REPORT"
 
 	if _, err := p.AddGroup("mutation-framework", "mutation-framework arguments", opts); err != nil {
 		return true, exitError(err.Error())
@@ -175,7 +227,7 @@ func checkArguments(args []string, opts *Args) (bool, int) {
 
 	completion := len(os.Getenv("GO_FLAGS_COMPLETION")) > 0
 
-	_, err := p.ParseArgs(args)
+	rest, err := p.ParseArgs(args)
 	if (opts.General.Help || len(args) == 0) && !completion {
 		p.WriteHelp(os.Stdout)
 
@@ -191,14 +243,26 @@ func checkArguments(args []string, opts *Args) (bool, int) {
 		return true, exitError(err.Error())
 	}
 
-	if completion {
-		return true, returnBashCompletion
-	}
-
 	if opts.General.Debug {
 		opts.General.Verbose = true
 	}
 
+	// mutation-framework compare old.json new.json
+	if len(rest) > 0 && rest[0] == "compare" {
+		// there is no config to set up logging from, only the flags
+		setUpLogging(&MutationConfig{Verbose: opts.General.Verbose, Json: opts.General.Json})
+		return true, compareReports(rest[1:])
+	}
+
+	// the config is only optional for comparing reports
+	if opts.General.ConfigPath == "" && !completion {
+		return true, exitError("the required flag `--config' was not specified")
+	}
+
+	if completion {
+		return true, returnBashCompletion
+	}
+
 	return false, 0
 }
 
@@ -225,6 +289,10 @@ func consolidateArgsIntoConfig(opts *Args, config *MutationConfig) {
 		config.Test.Composition = opts.Exec.Composition
 	}
 
+	if opts.Exec.Nodes != 0 {
+		config.Test.Nodes = opts.Exec.Nodes
+	}
+
 	if opts.Exec.Timeout != 0 {
 		config.Test.Timeout = opts.Exec.Timeout
 	}
@@ -236,6 +304,78 @@ func consolidateArgsIntoConfig(opts *Args, config *MutationConfig) {
 	if opts.Exec.Overwrite {
 		config.Mutate.Overwrite = true
 	}
+
+	if opts.Exec.Workers != 0 {
+		config.Test.Workers = opts.Exec.Workers
+	}
+
+	if opts.Exec.Since != "" {
+		config.Mutate.Since = opts.Exec.Since
+	}
+
+	if opts.Exec.Overlay {
+		config.Mutate.Overlay = true
+	}
+
+	if opts.Exec.Schemata {
+		config.Mutate.Schemata = true
+	}
+
+	if opts.Exec.Workspace != "" {
+		config.Mutate.Workspace = opts.Exec.Workspace
+	}
+
+	if opts.Exec.Roles != "" {
+		config.Mutate.Roles.File = opts.Exec.Roles
+	}
+
+	if len(opts.Exec.Role) > 0 {
+		config.Mutate.Roles.Names = opts.Exec.Role
+	}
+
+	if opts.Exec.Suppressions != "" {
+		config.Mutate.Suppressions = opts.Exec.Suppressions
+	}
+
+	if opts.Exec.DetectEquivalent {
+		config.Test.DetectEquivalent = true
+	}
+
+	if opts.Exec.KillMatrix != "" {
+		config.Test.KillMatrix = opts.Exec.KillMatrix
+	}
+
+	if opts.Exec.Report != "" {
+		config.Test.Report = opts.Exec.Report
+	}
+
+	if opts.Exec.HtmlReport != "" {
+		config.Test.HtmlReport = opts.Exec.HtmlReport
+	}
+
+	if opts.Exec.JunitReport != "" {
+		config.Test.JunitReport = opts.Exec.JunitReport
+	}
+
+	if opts.Exec.ElementsReport != "" {
+		config.Test.ElementsReport = opts.Exec.ElementsReport
+	}
+
+	if opts.Exec.MinScore != nil {
+		config.Test.MinScore = opts.Exec.MinScore
+	}
+
+	if opts.Exec.MaxSurvivors != nil {
+		config.Test.MaxSurvivors = opts.Exec.MaxSurvivors
+	}
+
+	if opts.Exec.Cache != "" {
+		config.Test.Cache = opts.Exec.Cache
+	}
+
+	if opts.Exec.Resume {
+		config.Test.Resume = true
+	}
 }
 
 func main() {
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// Name of the manifest inside the mutant folder
+const manifestFile = "manifest.json"
+
+// 2 added the storage of the mutants
+const manifestSchemaVersion = 2
+
+// Everything the exec phase needs to know about the mutants, written by the mutate phase
+// Paths are relative, so that the mutant folder can be executed on another machine.
+type mutantManifest struct {
+	SchemaVersion int `json:"schema_version"`
+	// copy, overlay or schemata, the mutants can only be executed the way they were stored
+	Storage string           `json:"storage"`
+	Mutants []manifestMutant `json:"mutants"`
+}
+
+type manifestMutant struct {
+	// folder of the mutant, relative to the mutant folder
+	Name string `json:"name"`
+	// the mutated file, relative to the project root and to the mutant
+	File        string `json:"file"`
+	Package     string `json:"package,omitempty"`
+	PackageName string `json:"package_name,omitempty"`
+	Operator    string `json:"operator"`
+	Checksum    string `json:"checksum"`
+	Line        int    `json:"line"`
+	Column      int    `json:"column"`
+	Role        string `json:"role,omitempty"`
+	Key         string `json:"key,omitempty"`
+}
+
+func getManifestPath(config *MutationConfig) string {
+	return appendFolder(getAbsoluteMutationFolderPath(config), manifestFile)
+}
+
+func newManifestMutant(config *MutationConfig, info MutantInfo) manifestMutant {
+	originalFilePath := concatAddingSlashIfNeeded(config.ProjectRoot, info.originalFileRelativePath)
+	diff := getMutantDiff(originalFilePath, info.mutationFileAbsPath, info.originalFileRelativePath)
+	line, column := getMutationPosition(diff)
+
+	mutant := manifestMutant{
+		Name:     getMutantName(config, info),
+		File:     filepath.ToSlash(info.originalFileRelativePath),
+		Operator: info.operator,
+		Checksum: info.checksum,
+		Line:     line,
+		Column:   column,
+		Role:     info.role,
+		Key:      info.key,
+	}
+	if info.pkg != nil {
+		mutant.Package = info.pkg.Path()
+		mutant.PackageName = info.pkg.Name()
+	}
+
+	return mutant
+}
+
+// Writes the manifest of the mutants to the mutant folder
+func writeMutantManifest(config *MutationConfig, mutants []MutantInfo) error {
+	manifest := mutantManifest{SchemaVersion: manifestSchemaVersion, Storage: config.Mutate.getStorage(),
+		Mutants: []manifestMutant{}}
+	for _, info := range mutants {
+		manifest.Mutants = append(manifest.Mutants, newManifestMutant(config, info))
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = FS.MkdirAll(getAbsoluteMutationFolderPath(config), 0755)
+	if err != nil {
+		return err
+	}
+
+	err = afero.WriteFile(FS, getManifestPath(config), data, 0644)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"path": getManifestPath(config), "mutants": len(manifest.Mutants)}).
+		Info("Wrote mutant manifest.")
+	return nil
+}
+
+// Reads the manifest of the mutant folder and returns the mutants of the files to execute
+func readMutantManifest(config *MutationConfig, allStats map[string]*mutationStats, filesToExec map[string]string) ([]MutantInfo, error) {
+	path := getManifestPath(config)
+	data, err := afero.ReadFile(FS, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest mutantManifest
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a mutant manifest: %v", path, err)
+	}
+
+	if manifest.SchemaVersion != manifestSchemaVersion {
+		return nil, fmt.Errorf("%s has schema version %d, but only %d can be executed",
+			path, manifest.SchemaVersion, manifestSchemaVersion)
+	}
+
+	if manifest.Storage != config.Mutate.getStorage() {
+		return nil, fmt.Errorf("%s holds %s mutants, but the config stores them as %s; run with the same storage as when mutating",
+			path, manifest.Storage, config.Mutate.getStorage())
+	}
+
+	mutantFolder := getAbsoluteMutationFolderPath(config)
+	var mutants []MutantInfo
+	for _, mutant := range manifest.Mutants {
+		file := filepath.FromSlash(mutant.File)
+		if _, ok := filesToExec[file]; !ok {
+			log.WithField("mutant", mutant.Name).Debug("Mutated file is not included, skipping mutant.")
+			continue
+		}
+
+		mutantDir := filepath.Clean(appendFolder(mutantFolder, filepath.FromSlash(mutant.Name)))
+		mutationFile := appendFolder(mutantDir, file)
+		if _, err := FS.Stat(mutationFile); err != nil {
+			return nil, fmt.Errorf("mutant %s of the manifest is missing: %v", mutant.Name, err)
+		}
+
+		if _, ok := allStats[file]; !ok {
+			allStats[file] = &mutationStats{}
+		}
+
+		var pkg *types.Package
+		if mutant.Package != "" {
+			pkg = types.NewPackage(mutant.Package, mutant.PackageName)
+		}
+
+		log.WithField("path", mutationFile).Debug("Found mutant.")
+		mutants = append(mutants, MutantInfo{pkg, file, mutantDir, mutationFile, mutant.Checksum, mutant.Operator, mutant.Role, mutant.Key})
+	}
+
+	return mutants, nil
+}
+
+// The mutants to execute without mutating. Mutant folders written before
+// there was a manifest are searched for mutants instead.
+func findMutants(config *MutationConfig, allStats map[string]*mutationStats, filesToExec map[string]string) ([]MutantInfo, error) {
+	mutants, err := readMutantManifest(config, allStats, filesToExec)
+	if os.IsNotExist(err) {
+		log.WithField("path", getManifestPath(config)).Warn("No mutant manifest, searching the mutant folder for mutants.")
+		return findAllMutantsInFolder(config, allStats, filesToExec)
+	}
+
+	return mutants, err
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutantManifestRoundTrip(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := &MutationConfig{ProjectRoot: "/project"}
+	config.Mutate.MutantFolder = "mutants/"
+
+	mutants := []MutantInfo{
+		{types.NewPackage("example.com/calc", "calc"), "calc/calc.go", "/project/mutants/calc/calc.go.branch-if.0",
+			"/project/mutants/calc/calc.go.branch-if.0/calc/calc.go", "abc", "branch/if", "leader", "calc/calc.go:branch/if:0a1b2c3d4e5f#1"},
+		{nil, "main.go", "/project/mutants/main.go.statement-remove.2",
+			"/project/mutants/main.go.statement-remove.2/main.go", "def", "statement/remove", "", ""},
+	}
+	for _, mutant := range mutants {
+		afero.WriteFile(FS, mutant.mutationFileAbsPath, []byte("package calc\n"), 0644)
+	}
+
+	assert.Nil(t, writeMutantManifest(config, mutants))
+
+	// executed somewhere else
+	config.ProjectRoot = "/elsewhere"
+	config.Mutate.MutantFolder = "/project/mutants/"
+
+	stats := make(map[string]*mutationStats)
+	found, err := readMutantManifest(config, stats, map[string]string{"calc/calc.go": "/elsewhere/calc/calc.go"})
+	assert.Nil(t, err)
+	assert.Len(t, found, 1)
+	assert.Contains(t, stats, "calc/calc.go")
+	assert.NotContains(t, stats, "main.go")
+
+	mutant := found[0]
+	assert.Equal(t, "calc/calc.go", mutant.originalFileRelativePath)
+	assert.Equal(t, "/project/mutants/calc/calc.go.branch-if.0", mutant.mutantDirPathAbsPath)
+	assert.Equal(t, "/project/mutants/calc/calc.go.branch-if.0/calc/calc.go", mutant.mutationFileAbsPath)
+	assert.Equal(t, "abc", mutant.checksum)
+	assert.Equal(t, "branch/if", mutant.operator)
+	assert.Equal(t, "leader", mutant.role)
+	assert.Equal(t, "calc/calc.go:branch/if:0a1b2c3d4e5f#1", mutant.key)
+	assert.Equal(t, "example.com/calc", mutant.pkg.Path())
+	assert.Equal(t, "calc", mutant.pkg.Name())
+}
+
+func TestMutantManifestMissingMutant(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := &MutationConfig{ProjectRoot: "/project"}
+	config.Mutate.MutantFolder = "mutants/"
+
+	mutants := []MutantInfo{{nil, "main.go", "/project/mutants/main.go.branch-if.0",
+		"/project/mutants/main.go.branch-if.0/main.go", "abc", "branch/if", "", ""}}
+	assert.Nil(t, writeMutantManifest(config, mutants))
+
+	_, err := readMutantManifest(config, make(map[string]*mutationStats), map[string]string{"main.go": "/project/main.go"})
+	assert.Error(t, err)
+}
+
+func TestMutantManifestStorage(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := &MutationConfig{ProjectRoot: "/project"}
+	config.Mutate.MutantFolder = "mutants/"
+	config.Mutate.Overlay = true
+
+	mutants := []MutantInfo{{nil, "main.go", "/project/mutants/main.go.branch-if.0",
+		"/project/mutants/main.go.branch-if.0/main.go", "abc", "branch/if", "", ""}}
+	afero.WriteFile(FS, mutants[0].mutationFileAbsPath, []byte("package main\n"), 0644)
+	assert.Nil(t, writeMutantManifest(config, mutants))
+
+	files := map[string]string{"main.go": "/project/main.go"}
+	found, err := readMutantManifest(config, make(map[string]*mutationStats), files)
+	assert.Nil(t, err)
+	assert.Len(t, found, 1)
+
+	// overlay mutants are no copies of the project, and have no schemata
+	config.Mutate.Overlay = false
+	_, err = readMutantManifest(config, make(map[string]*mutationStats), files)
+	assert.Error(t, err)
+
+	config.Mutate.Schemata = true
+	_, err = readMutantManifest(config, make(map[string]*mutationStats), files)
+	assert.Error(t, err)
+}
+
+func TestMutantManifestSchemaVersion(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := &MutationConfig{ProjectRoot: "/project"}
+	config.Mutate.MutantFolder = "mutants/"
+	afero.WriteFile(FS, getManifestPath(config), []byte(`{"schema_version": 99, "mutants": []}`), 0644)
+
+	_, err := readMutantManifest(config, make(map[string]*mutationStats), nil)
+	assert.Error(t, err)
+}
@@ -24,6 +24,12 @@ type MutantInfo struct {
 	mutantDirPathAbsPath     string
 	mutationFileAbsPath      string
 	checksum                 string
+	// name of the mutation operator, e.g. branch/if
+	operator string
+	// node role of the mutated lines, empty unless mutation is restricted to roles, see --roles
+	role string
+	// identifies the mutant across runs, see assignStableKeys; empty if it is not known
+	key string
 }
 
 // Creates the mutant folder, checks each file, and feeds them into mutate()
@@ -32,6 +38,16 @@ func mutateFiles(config *MutationConfig, files map[string]string) (map[string]*m
 	allStats := make(map[string]*mutationStats)
 	var allMutantInfo []MutantInfo
 
+	suppressions, err := loadSuppressions(config)
+	if err != nil {
+		return nil, nil, exitError("Could not read suppressed mutants: %v", err)
+	}
+
+	roles, err := loadRoles(config)
+	if err != nil {
+		return nil, nil, exitError("Could not read node roles: %v", err)
+	}
+
 	for relativeFileLocation, abs := range files {
 		stats := &mutationStats{}
 		allStats[relativeFileLocation] = stats
@@ -57,11 +73,16 @@ func mutateFiles(config *MutationConfig, files map[string]string) (map[string]*m
 		mutationID := 0
 
 		mutantInfo := mutate(config, mutationID, pkg, info, abs, relativeFileLocation,
-			fset, src, src, stats)
+			fset, src, src, stats, suppressions, roles)
 
 		allMutantInfo = append(allMutantInfo, mutantInfo...)
 	}
 
+	err = writeMutantManifest(config, allMutantInfo)
+	if err != nil {
+		return nil, nil, exitError("Could not write mutant manifest: %v", err)
+	}
+
 	return allStats, allMutantInfo, returnOk
 }
 
@@ -85,18 +106,46 @@ func createMutantFolderPath(file string) {
  */
 func mutate(config *MutationConfig, mutationID int, pkg *types.Package,
 	info *types.Info, file string, relativeFilePath string, fset *token.FileSet,
-	src ast.Node, node ast.Node, stats *mutationStats) []MutantInfo {
+	src ast.Node, node ast.Node, stats *mutationStats, suppressions *suppressionList, roles *nodeRoles) []MutantInfo {
 
-	// Save information about mutant paths in order to
-	// pass them to the execution stage
-	var mutantInfos []MutantInfo
+	// the mutants are printed first and only created once it is known which are suppressed
+	var planned []plannedMutant
+
+	// checksums of the mutants of this file, to leave out mutants that are the same as an earlier one
+	mutationBlackList := make(map[string]struct{})
+	// nil unless only some lines are mutated, see --since and --roles
+	var filter func(pos token.Pos, end token.Pos) bool
+	if changedLines, ok := config.Mutate.changedLines[relativeFilePath]; ok {
+		filter = newChangedLinesFilter(fset, changedLines)
+	}
+	// the role of the code which is mutated, set by the filter
+	var role string
+	if roles != nil {
+		ranges := roles.getRanges(config, relativeFilePath)
+		if len(ranges) == 0 {
+			log.WithField("file", relativeFilePath).Info("File has no lines of the selected roles, not mutating it.")
+			return nil
+		}
+		filter = bothFilters(filter, newRoleFilter(fset, ranges, &role))
+	}
+
+	// nil unless the mutants are woven into a schemata, see --schemata
+	var weaver *schemataWeaver
+	if config.Mutate.Schemata {
+		source, err := afero.ReadFile(FS, file)
+		if err != nil {
+			log.WithField("file", relativeFilePath).Error(err)
+			return nil
+		}
+		weaver = newSchemataWeaver(fset, src, source)
+	}
 
 	for _, m := range config.Mutate.Operators {
 		mutationID = 0
 		log.WithField("mutation_operator", m.Name).Info("Mutating.")
 
 		// Walk the AST for this mutation operator
-		changed := mutesting.MutateWalk(pkg, info, node, *m.MutationOperator)
+		changed := mutesting.MutateWalkFiltered(pkg, info, node, *m.MutationOperator, countSkipped(filter, &mutationID))
 
 		for {
 			// Has the AST been changed by a mutation?
@@ -106,51 +155,113 @@ func mutate(config *MutationConfig, mutationID int, pkg *types.Package,
 				break
 			}
 
-			mutationBlackList := make(map[string]struct{},0) //TODO implement real blacklisting
-
-			// set up new folder for mutant
 			mutationFileId := buildMutantName(m.Name, relativeFilePath, mutationID)
-			log.WithField("name", mutationFileId).Info("Creating mutant.")
+			checksum, source, duplicate, err := printMutant(mutationBlackList, fset, src)
 
-			mutantPath, err := copyProject(config, mutationFileId) // TODO verify correctness of absolute file
-			if err != nil {
-				log.WithField("error", err).Error("Internal error.")
-			}
-
-			// get the absolute path of the mutated file inside the mutant
-			mutatedFilePath := appendFolder(filepath.Clean(mutantPath), relativeFilePath)
-			checksum, duplicate, err := saveAST(mutationBlackList, mutatedFilePath, fset, src)
-
-			// save the AST with mutation to mutant
 			if err != nil {
 				log.WithField("error", err).Error("Internal error.")
 			} else if duplicate {
-				log.WithField("mutant", mutatedFilePath).Debug("Ignoring duplicate.")
+				log.WithField("name", mutationFileId).Debug("Ignoring duplicate.")
 				stats.duplicated++
 			} else {
-				log.WithFields(
-					log.Fields{"mutant": mutatedFilePath, "checksum": checksum}).
-					Debug("Saving mutated file.")
+				// the folder of the mutant and the absolute path of the mutated file inside it
+				mutantPath := filepath.Clean(appendFolder(getAbsoluteMutationFolderPath(config), mutationFileId))
+				mutatedFilePath := appendFolder(mutantPath, relativeFilePath)
 
 				// Bundle up information about the mutant and send to exec
-				mutantInfo := MutantInfo{pkg, relativeFilePath,
-					filepath.Clean(mutantPath),
-					mutatedFilePath, checksum}
-				mutantInfos = append(mutantInfos, mutantInfo)
+				mutantInfo := MutantInfo{pkg, relativeFilePath, mutantPath,
+					mutatedFilePath, checksum, m.Name, role, ""}
+				planned = append(planned, plannedMutant{mutationFileId, mutantInfo, source})
+
+				if weaver != nil && !weaver.addVariant(src, checksum) {
+					log.WithField("mutant", mutatedFilePath).
+						Debug("Mutation is outside of any statement list, it is not woven into the schemata.")
+				}
 			}
 
 			changed <- true
 
 			// Ignore original state
 			<-changed
+			// counted before the walk goes on, since the filter counts as well
+			mutationID++
 			changed <- true
+		}
+	}
 
-			mutationID++
+	// the keys count every mutant of the file, so they are assigned once all are printed
+	assignStableKeys(config, planned)
+	planned, suppressed := removeSuppressed(suppressions, planned)
+	for _, mutant := range suppressed {
+		stats.suppressed++
+		if weaver != nil {
+			weaver.removeVariant(mutant.info.checksum)
 		}
 	}
+
+	// Save information about mutant paths in order to
+	// pass them to the execution stage
+	var mutantInfos []MutantInfo
+	for _, mutant := range planned {
+		log.WithField("name", mutant.name).Info("Creating mutant.")
+
+		err := createMutant(config, mutant)
+		if err != nil {
+			log.WithFields(log.Fields{"name": mutant.name, "error": err}).Error("Internal error.")
+			if weaver != nil {
+				weaver.removeVariant(mutant.info.checksum)
+			}
+			continue
+		}
+
+		mutantInfos = append(mutantInfos, mutant.info)
+	}
+
+	if weaver != nil {
+		err := writeSchemata(config, weaver, relativeFilePath, pkg.Name(), mutantInfos)
+		if err != nil {
+			log.WithFields(log.Fields{"file": relativeFilePath, "error": err}).
+				Warn("Could not write schemata, testing the mutants of the file on their own.")
+		}
+	}
+
 	return mutantInfos
 }
 
+// A mutant which is printed but not created yet, see mutate
+type plannedMutant struct {
+	// folder of the mutant, relative to the mutant folder
+	name   string
+	info   MutantInfo
+	source []byte
+}
+
+// Creates the folder of a mutant, as a copy of the project or as an overlay, and writes the mutated file
+func createMutant(config *MutationConfig, mutant plannedMutant) error {
+	var err error
+	if config.Mutate.usesOverlay() {
+		_, err = createOverlayMutant(config, mutant.name, mutant.info.originalFileRelativePath)
+	} else {
+		_, err = copyProject(config, mutant.name) // TODO verify correctness of absolute file
+	}
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(
+		log.Fields{"mutant": mutant.info.mutationFileAbsPath, "checksum": mutant.info.checksum}).
+		Debug("Saving mutated file.")
+	return writeMutantFile(mutant.info.mutationFileAbsPath, mutant.source)
+}
+
+// Removes the folder of a mutant that is not executed
+func removeMutant(mutantPath string) {
+	err := FS.RemoveAll(mutantPath)
+	if err != nil {
+		log.WithFields(log.Fields{"mutant": mutantPath, "error": err}).Warn("Could not remove mutant.")
+	}
+}
+
 func buildMutantName(operatorName string, filePath string, mutationId int) string {
 	// replace slash so "branch/go" becomes "branch-go" and doesn't create new directory
 	safeMutationName := strings.Replace(operatorName, string(os.PathSeparator), "-", -1)
@@ -168,36 +279,70 @@ func getAbsoluteMutationFolderPath(config *MutationConfig) (projectName string)
 	return
 }
 
-func saveAST(mutationBlackList map[string]struct{}, file string, fset *token.FileSet, node ast.Node) (string, bool, error) { // TODO blacklists -- don't currently have this capability
+// Prints the mutated AST unless an earlier mutant of the blacklist has the same checksum
+func printMutant(mutationBlackList map[string]struct{}, fset *token.FileSet, node ast.Node) (string, []byte, bool, error) {
 	var buf bytes.Buffer
 
 	h := md5.New()
 
 	err := printer.Fprint(io.MultiWriter(h, &buf), fset, node)
 	if err != nil {
-		return "", false, err
+		return "", nil, false, err
 	}
 
 	checksum := fmt.Sprintf("%x", h.Sum(nil))
 
 	if _, ok := mutationBlackList[checksum]; ok {
-		return checksum, true, nil
+		return checksum, nil, true, nil
 	}
 
 	mutationBlackList[checksum] = struct{}{}
 
 	src, err := format.Source(buf.Bytes())
 	if err != nil {
-		return "", false, err
+		return "", nil, false, err
+	}
+
+	return checksum, src, false, nil
+}
+
+// Writes the mutated source to the file of the mutant
+func writeMutantFile(file string, src []byte) error {
+	// the file may be a link of the original, see workspace strategies, so it is replaced rather than written
+	err := FS.Remove(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
 	}
 
 	err = afero.WriteFile(FS, file, src, 0666)
 	fmt.Println("Made change: ", src)
-	if err != nil {
-		return "", false, err
+	return err
+}
+
+// Counts the mutations the filter leaves out, so that a mutant has the same name as without the filter
+func countSkipped(filter func(pos token.Pos, end token.Pos) bool, mutationID *int) func(pos token.Pos, end token.Pos) bool {
+	if filter == nil {
+		return nil
 	}
 
-	return checksum, false, nil
+	return func(pos token.Pos, end token.Pos) bool {
+		if filter(pos, end) {
+			return true
+		}
+
+		*mutationID++
+		return false
+	}
 }
 
+// Accepts the mutations both filters accept, the second one is only asked if the first one accepts
+func bothFilters(first func(pos token.Pos, end token.Pos) bool,
+	second func(pos token.Pos, end token.Pos) bool) func(pos token.Pos, end token.Pos) bool {
+	if first == nil {
+		return second
+	}
 
+	return func(pos token.Pos, end token.Pos) bool {
+		return first(pos, end) && second(pos, end)
+	}
+}
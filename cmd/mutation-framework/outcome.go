@@ -0,0 +1,161 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// What running the tests against a mutant revealed
+type mutantOutcome int
+
+const (
+	outcomeKilled mutantOutcome = iota
+	outcomeSurvived
+	outcomeTimedOut
+	outcomeCrashed
+	outcomeNotCompiling
+	// compiles to the same code as the original, so the tests were not run
+	outcomeEquivalent
+	// the tests could not be run against the mutant, e.g. the cluster did not start
+	outcomeError
+)
+
+func (outcome mutantOutcome) String() string {
+	switch outcome {
+	case outcomeKilled:
+		return "killed"
+	case outcomeSurvived:
+		return "survived"
+	case outcomeTimedOut:
+		return "timed out"
+	case outcomeCrashed:
+		return "crashed"
+	case outcomeNotCompiling:
+		return "not compiling"
+	case outcomeEquivalent:
+		return "equivalent"
+	case outcomeError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// Whether the tests noticed the mutant in some way
+func (outcome mutantOutcome) isDetected() bool {
+	return outcome == outcomeKilled || outcome == outcomeTimedOut || outcome == outcomeCrashed
+}
+
+// One run of the tests against a mutant
+type testRun struct {
+	outcome     mutantOutcome
+	failedTests []string
+	// nil unless the test command wrote go test -json
+	tests    []*testResult
+	duration time.Duration
+	// outcome by composition, nil unless compositions are configured
+	compositions map[string]mutantOutcome
+}
+
+// Everything that is known about a mutant after executing it
+type mutantResult struct {
+	mutant      MutantInfo
+	outcome     mutantOutcome
+	failedTests []string
+	tests       []*testResult
+	duration    time.Duration
+	// every run of the tests, the first one included
+	runs []*testRun
+	// outcome by composition of the verdict, nil unless compositions are configured
+	compositions map[string]mutantOutcome
+}
+
+func newMutantResult(mutant MutantInfo, run *testRun) *mutantResult {
+	return &mutantResult{
+		mutant:       mutant,
+		outcome:      run.outcome,
+		failedTests:  run.failedTests,
+		tests:        run.tests,
+		duration:     run.duration,
+		runs:         []*testRun{run},
+		compositions: run.compositions,
+	}
+}
+
+// Whether the tests could not be run against the mutant, in any of the compositions
+func (result *mutantResult) hasErrors() bool {
+	if result.outcome == outcomeError {
+		return true
+	}
+
+	for _, outcome := range result.compositions {
+		if outcome == outcomeError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The share of runs that agree with the verdict
+func (result *mutantResult) confidence() float64 {
+	if len(result.runs) == 0 {
+		return 0.0
+	}
+
+	agreeing := 0
+	for _, run := range result.runs {
+		if run.outcome == result.outcome {
+			agreeing++
+		}
+	}
+
+	return float64(agreeing) / float64(len(result.runs))
+}
+
+var panicPattern = regexp.MustCompile(`(?m)^panic: `)
+
+// Decide the outcome of a mutant from its test command
+func classifyTestRun(result *commandResult) mutantOutcome {
+	output := string(result.output)
+
+	switch {
+	case result.timedOut || strings.Contains(output, "panic: test timed out after"):
+		// killed by us or by the -timeout of go test
+		return outcomeTimedOut
+	case result.exitCode == 0 && !result.signaled:
+		return outcomeSurvived
+	case strings.Contains(output, "[build failed]") || strings.Contains(output, "[setup failed]"):
+		return outcomeNotCompiling
+	case result.signaled || panicPattern.MatchString(output):
+		return outcomeCrashed
+	case result.exitCode == 1:
+		return outcomeKilled
+	case result.exitCode == 2:
+		// exec scripts report mutants that don't compile with 2
+		return outcomeNotCompiling
+	default:
+		return outcomeCrashed
+	}
+}
+
+// Count the outcome of one mutant of this file
+func (ms *mutationStats) record(outcome mutantOutcome) {
+	switch outcome {
+	case outcomeKilled:
+		ms.passed++
+	case outcomeSurvived:
+		ms.failed++
+	case outcomeTimedOut:
+		ms.timedOut++
+	case outcomeCrashed:
+		ms.crashed++
+	case outcomeNotCompiling:
+		ms.skipped++
+	case outcomeEquivalent:
+		ms.equivalent++
+	case outcomeError:
+		ms.errors++
+	}
+}
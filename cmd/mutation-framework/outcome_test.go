@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyTestRun(t *testing.T) {
+	assert.Equal(t, outcomeSurvived, classifyTestRun(&commandResult{exitCode: 0,
+		output: []byte("ok  \tgithub.com/amyjzhu/mutation-framework/example\t0.004s")}))
+	assert.Equal(t, outcomeKilled, classifyTestRun(&commandResult{exitCode: 1,
+		output: []byte("--- FAIL: TestFoo (0.00s)\nFAIL")}))
+	assert.Equal(t, outcomeTimedOut, classifyTestRun(&commandResult{exitCode: -1, signaled: true, timedOut: true}))
+	assert.Equal(t, outcomeTimedOut, classifyTestRun(&commandResult{exitCode: 1,
+		output: []byte("panic: test timed out after 1s\n\ngoroutine 6 [running]:")}))
+	assert.Equal(t, outcomeCrashed, classifyTestRun(&commandResult{exitCode: 1,
+		output: []byte("panic: runtime error: index out of range\n\ngoroutine 6 [running]:")}))
+	assert.Equal(t, outcomeCrashed, classifyTestRun(&commandResult{exitCode: -1, signaled: true}))
+	assert.Equal(t, outcomeCrashed, classifyTestRun(&commandResult{exitCode: 3}))
+	assert.Equal(t, outcomeNotCompiling, classifyTestRun(&commandResult{exitCode: 1,
+		output: []byte("FAIL\tgithub.com/amyjzhu/mutation-framework/example [build failed]")}))
+	assert.Equal(t, outcomeNotCompiling, classifyTestRun(&commandResult{exitCode: 2}))
+}
+
+func TestRecordOutcomes(t *testing.T) {
+	stats := &mutationStats{}
+	for _, outcome := range []mutantOutcome{outcomeKilled, outcomeKilled, outcomeSurvived,
+		outcomeTimedOut, outcomeCrashed, outcomeNotCompiling, outcomeError} {
+		stats.record(outcome)
+	}
+
+	assert.Equal(t, 2, stats.passed)
+	assert.Equal(t, 1, stats.failed)
+	assert.Equal(t, 1, stats.timedOut)
+	assert.Equal(t, 1, stats.crashed)
+	assert.Equal(t, 1, stats.skipped)
+	assert.Equal(t, 1, stats.errors)
+	assert.Equal(t, 6, stats.Total())
+	assert.InDelta(t, 4.0/6.0, stats.Score(), 0.0001)
+}
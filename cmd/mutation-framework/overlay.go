@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// Name of the overlay inside an overlay mutant
+const overlayFile = "overlay.json"
+
+// The file that go build -overlay reads, which replaces files of the build by other files
+type buildOverlay struct {
+	Replace map[string]string `json:"Replace"`
+}
+
+func getOverlayPath(mutantDir string) string {
+	return appendFolder(mutantDir, overlayFile)
+}
+
+// Creates the folder of an overlay mutant, which only holds the mutated file and the overlay
+// that replaces the original by it. Returns the path of the folder.
+func createOverlayMutant(config *MutationConfig, name string, relativeFilePath string) (string, error) {
+	mutantDir := filepath.Clean(appendFolder(getAbsoluteMutationFolderPath(config), name))
+
+	_, err := FS.Stat(mutantDir)
+	if err == nil && !config.Mutate.Overwrite {
+		return "", fmt.Errorf("this action would overwrite %s", mutantDir)
+	} else if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	mutatedFilePath := appendFolder(mutantDir, relativeFilePath)
+	err = FS.MkdirAll(filepath.Dir(mutatedFilePath), 0755)
+	if err != nil {
+		return "", err
+	}
+
+	// the mutated file alone does not build, so ./... of the project must not descend into it
+	err = afero.WriteFile(FS, appendFolder(mutantDir, "go.mod"), []byte("module mutant\n"), 0644)
+	if err != nil {
+		return "", err
+	}
+
+	// an overwritten mutant may not be part of the schemata of an earlier run
+	err = FS.Remove(getSchemataOverlayPath(mutantDir))
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	return mutantDir, writeOverlay(config, mutantDir, relativeFilePath)
+}
+
+func writeOverlay(config *MutationConfig, mutantDir string, relativeFilePath string) error {
+	original, err := filepath.Abs(concatAddingSlashIfNeeded(config.ProjectRoot, relativeFilePath))
+	if err != nil {
+		return err
+	}
+
+	mutated, err := filepath.Abs(appendFolder(mutantDir, relativeFilePath))
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(buildOverlay{map[string]string{original: mutated}}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"mutant": mutantDir, "original": original}).Debug("Writing overlay.")
+	return afero.WriteFile(FS, getOverlayPath(mutantDir), data, 0644)
+}
+
+// Sets up the workspace of an overlay mutant, whose commands run in the project itself
+// and see the mutated file through the overlay. The overlay is written again, since its
+// absolute paths may be of another machine, see --no-mutate.
+func newOverlayWorkspace(config *MutationConfig, mutantInfo MutantInfo) (*mutantWorkspace, error) {
+	overlay := getOverlayPath(mutantInfo.mutantDirPathAbsPath)
+	if _, err := FS.Stat(overlay); err != nil {
+		return nil, fmt.Errorf("mutant has no overlay: %v", err)
+	}
+
+	err := writeOverlay(config, mutantInfo.mutantDirPathAbsPath, mutantInfo.originalFileRelativePath)
+	if err != nil {
+		return nil, err
+	}
+
+	workspace, err := newProjectWorkspace(config)
+	if err != nil {
+		return nil, err
+	}
+
+	workspace.overlay = overlay
+	workspace.env = setEnv(workspace.env, "MUTATE_OVERLAY", overlay)
+
+	return workspace, nil
+}
+
+// A workspace for commands which only read the project, so they can run in the project itself
+func newProjectWorkspace(config *MutationConfig) (*mutantWorkspace, error) {
+	dir, err := filepath.Abs(config.ProjectRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mutantWorkspace{
+		dir: dir,
+		env: setEnv(os.Environ(), "PWD", dir),
+	}, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateOverlayMutant(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := &MutationConfig{ProjectRoot: "/project/"}
+	config.Mutate.MutantFolder = "mutants/"
+
+	mutantDir, err := createOverlayMutant(config, "calc/calc.go.branch-if.0", "calc/calc.go")
+	assert.Nil(t, err)
+	assert.Equal(t, "/project/mutants/calc/calc.go.branch-if.0", mutantDir)
+
+	exists, _ := afero.DirExists(FS, "/project/mutants/calc/calc.go.branch-if.0/calc")
+	assert.True(t, exists)
+	exists, _ = afero.Exists(FS, "/project/mutants/calc/calc.go.branch-if.0/go.mod")
+	assert.True(t, exists)
+
+	data, err := afero.ReadFile(FS, "/project/mutants/calc/calc.go.branch-if.0/overlay.json")
+	assert.Nil(t, err)
+
+	var overlay buildOverlay
+	assert.Nil(t, json.Unmarshal(data, &overlay))
+	assert.Equal(t, map[string]string{
+		"/project/calc/calc.go": "/project/mutants/calc/calc.go.branch-if.0/calc/calc.go",
+	}, overlay.Replace)
+
+	_, err = createOverlayMutant(config, "calc/calc.go.branch-if.0", "calc/calc.go")
+	assert.Error(t, err)
+
+	config.Mutate.Overwrite = true
+	_, err = createOverlayMutant(config, "calc/calc.go.branch-if.0", "calc/calc.go")
+	assert.Nil(t, err)
+}
+
+func TestOverlayWorkspace(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := &MutationConfig{ProjectRoot: "/project/"}
+	config.Mutate.MutantFolder = "mutants/"
+	config.Mutate.Overlay = true
+	config.Test.Timeout = 10
+
+	mutantDir, err := createOverlayMutant(config, "calc/calc.go.branch-if.0", "calc/calc.go")
+	assert.Nil(t, err)
+	mutant := MutantInfo{originalFileRelativePath: "calc/calc.go", mutantDirPathAbsPath: mutantDir,
+		mutationFileAbsPath: mutantDir + "/calc/calc.go"}
+
+	workspace, err := newMutantWorkspace(config, mutant)
+	assert.Nil(t, err)
+	assert.Equal(t, "/project", workspace.dir)
+	assert.Contains(t, workspace.env, "MUTATE_OVERLAY=/project/mutants/calc/calc.go.branch-if.0/overlay.json")
+
+	cmd := defaultTestCommand(workspace, "calc/calc.go")
+	assert.Equal(t, []string{"go", "test", "-count=1", "-json", "-timeout", "10s",
+		"-overlay", "/project/mutants/calc/calc.go.branch-if.0/overlay.json", "./calc"}, cmd.Args)
+
+	FS.Remove(getOverlayPath(mutantDir))
+	_, err = newMutantWorkspace(config, mutant)
+	assert.Error(t, err)
+}
+
+func TestOverlayWorkspaceOfAnotherProjectRoot(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := &MutationConfig{ProjectRoot: "/project/"}
+	config.Mutate.MutantFolder = "/mutants/"
+	config.Mutate.Overlay = true
+
+	mutantDir, err := createOverlayMutant(config, "calc/calc.go.branch-if.0", "calc/calc.go")
+	assert.Nil(t, err)
+	mutant := MutantInfo{originalFileRelativePath: "calc/calc.go", mutantDirPathAbsPath: mutantDir,
+		mutationFileAbsPath: mutantDir + "/calc/calc.go"}
+
+	// executed with the project checked out somewhere else
+	config.ProjectRoot = "/elsewhere/"
+	_, err = newMutantWorkspace(config, mutant)
+	assert.Nil(t, err)
+
+	data, _ := afero.ReadFile(FS, getOverlayPath(mutantDir))
+	var overlay buildOverlay
+	assert.Nil(t, json.Unmarshal(data, &overlay))
+	assert.Equal(t, map[string]string{"/elsewhere/calc/calc.go": "/mutants/calc/calc.go.branch-if.0/calc/calc.go"},
+		overlay.Replace)
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// Bump whenever a field of the report changes its meaning or goes away
+const reportSchemaVersion = 1
+
+// The report written with --report, which is meant to be read by other tools
+// Fields are only ever added to it, see reportSchemaVersion
+type runReport struct {
+	SchemaVersion int                     `json:"schema_version"`
+	ProjectRoot   string                  `json:"project_root"`
+	Total         reportScore             `json:"total"`
+	Files         map[string]*reportScore `json:"files"`
+	Operators     map[string]*reportScore `json:"operators"`
+	Packages      map[string]*reportScore `json:"packages"`
+	Roles         map[string]*reportScore `json:"roles,omitempty"`
+	// scores of each composition, and of the compositions by the role of their mutated nodes
+	Compositions map[string]*reportScore `json:"compositions,omitempty"`
+	NodeRoles    map[string]*reportScore `json:"node_roles,omitempty"`
+	Mutants      []reportMutant          `json:"mutants"`
+}
+
+type reportMutant struct {
+	Id string `json:"id"`
+	// identifies the mutant across runs, see getStableKeys
+	Key      string `json:"key"`
+	Operator string `json:"operator"`
+	File     string `json:"file"`
+	Package  string `json:"package"`
+	// position of the mutation in the original file, 0 if unknown
+	Line            int      `json:"line"`
+	Column          int      `json:"column"`
+	Diff            string   `json:"diff"`
+	Checksum        string   `json:"checksum"`
+	Outcome         string   `json:"outcome"`
+	DurationSeconds float64  `json:"duration_seconds"`
+	KilledBy        []string `json:"killed_by"`
+	Confidence      float64  `json:"confidence"`
+	Role            string   `json:"role,omitempty"`
+	// outcome by composition, see Test.Compositions
+	Compositions map[string]string `json:"compositions,omitempty"`
+}
+
+type reportScore struct {
+	Killed       int     `json:"killed"`
+	Survived     int     `json:"survived"`
+	TimedOut     int     `json:"timed_out"`
+	Crashed      int     `json:"crashed"`
+	NotCompiling int     `json:"not_compiling"`
+	Duplicated   int     `json:"duplicated"`
+	Suppressed   int     `json:"suppressed"`
+	Equivalent   int     `json:"equivalent"`
+	Errors       int     `json:"errors"`
+	Total        int     `json:"total"`
+	Score        float64 `json:"score"`
+}
+
+func newReportScore(stats *mutationStats) *reportScore {
+	return &reportScore{
+		Killed:       stats.passed,
+		Survived:     stats.failed,
+		TimedOut:     stats.timedOut,
+		Crashed:      stats.crashed,
+		NotCompiling: stats.skipped,
+		Duplicated:   stats.duplicated,
+		Suppressed:   stats.suppressed,
+		Equivalent:   stats.equivalent,
+		Errors:       stats.errors,
+		Total:        stats.Total(),
+		Score:        stats.Score(),
+	}
+}
+
+// Puts together the report of a run from the results of every mutant
+// Duplicated and suppressed mutants are only known per file, so they only show up in the file and total scores
+func newRunReport(config *MutationConfig, results []*mutantResult, allStats map[string]*mutationStats) *runReport {
+	report := &runReport{
+		SchemaVersion: reportSchemaVersion,
+		ProjectRoot:   config.ProjectRoot,
+		Files:         make(map[string]*reportScore),
+		Operators:     make(map[string]*reportScore),
+		Packages:      make(map[string]*reportScore),
+		Mutants:       []reportMutant{},
+	}
+
+	total := &mutationStats{}
+	operators := make(map[string]*mutationStats)
+	packages := make(map[string]*mutationStats)
+	roles := make(map[string]*mutationStats)
+
+	for _, result := range results {
+		mutant := newReportMutant(config, result)
+		report.Mutants = append(report.Mutants, mutant)
+
+		total.record(result.outcome)
+		recordIn(operators, mutant.Operator, result.outcome)
+		recordIn(packages, mutant.Package, result.outcome)
+		if mutant.Role != "" {
+			recordIn(roles, mutant.Role, result.outcome)
+		}
+	}
+
+	for file, stats := range allStats {
+		total.duplicated += stats.duplicated
+		total.suppressed += stats.suppressed
+		report.Files[file] = newReportScore(stats)
+	}
+	for operator, stats := range operators {
+		report.Operators[operator] = newReportScore(stats)
+	}
+	for pkg, stats := range packages {
+		report.Packages[pkg] = newReportScore(stats)
+	}
+	if len(roles) > 0 {
+		report.Roles = make(map[string]*reportScore)
+		for role, stats := range roles {
+			report.Roles[role] = newReportScore(stats)
+		}
+	}
+	report.Total = *newReportScore(total)
+
+	compositions, nodeRoles := getCompositionStats(config, results)
+	if len(compositions) > 0 {
+		report.Compositions = make(map[string]*reportScore)
+		for name, stats := range compositions {
+			report.Compositions[name] = newReportScore(stats)
+		}
+	}
+	if len(nodeRoles) > 0 {
+		report.NodeRoles = make(map[string]*reportScore)
+		for role, stats := range nodeRoles {
+			report.NodeRoles[role] = newReportScore(stats)
+		}
+	}
+
+	for _, mutant := range report.Mutants {
+		if mutant.Key == "" {
+			// found without a manifest, so the keys were not assigned when the mutants were created
+			setStableKeys(report.Mutants)
+			break
+		}
+	}
+	sort.Slice(report.Mutants, func(i, j int) bool {
+		return report.Mutants[i].Id < report.Mutants[j].Id
+	})
+
+	return report
+}
+
+// Gives every mutant a key which stays the same when code is added elsewhere in the file,
+// unlike the counter of buildMutantName. The key is made of the file, the operator and the
+// lines the mutation changes; mutants that agree on all of them are told apart by their order.
+// Reports get the keys of assignStableKeys, this is for mutants which were created without them.
+func setStableKeys(mutants []reportMutant) {
+	order := make([]int, len(mutants))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := mutants[order[i]], mutants[order[j]]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return a.Id < b.Id
+	})
+
+	occurrences := make(map[string]int)
+	for _, i := range order {
+		key := getStableKey(mutants[i])
+		occurrences[key]++
+		mutants[i].Key = fmt.Sprintf("%s#%d", key, occurrences[key])
+	}
+}
+
+func getStableKey(mutant reportMutant) string {
+	var changed []string
+	for _, line := range strings.Split(mutant.Diff, "\n") {
+		if strings.HasPrefix(line, "---") || strings.HasPrefix(line, "+++") {
+			continue
+		}
+		if strings.HasPrefix(line, "-") || strings.HasPrefix(line, "+") {
+			changed = append(changed, line[:1]+strings.TrimSpace(line[1:]))
+		}
+	}
+
+	hash := sha1.Sum([]byte(strings.Join(changed, "\n")))
+	return fmt.Sprintf("%s:%s:%x", mutant.File, mutant.Operator, hash[:6])
+}
+
+func recordIn(allStats map[string]*mutationStats, key string, outcome mutantOutcome) {
+	if allStats[key] == nil {
+		allStats[key] = &mutationStats{}
+	}
+	allStats[key].record(outcome)
+}
+
+func newReportMutant(config *MutationConfig, result *mutantResult) reportMutant {
+	info := result.mutant
+
+	originalFilePath := concatAddingSlashIfNeeded(config.ProjectRoot, info.originalFileRelativePath)
+	diff := getMutantDiff(originalFilePath, info.mutationFileAbsPath, info.originalFileRelativePath)
+	line, column := getMutationPosition(diff)
+
+	killedBy := []string{}
+	if result.outcome.isDetected() {
+		killedBy = append(killedBy, result.failedTests...)
+	}
+
+	return reportMutant{
+		Id:              getMutantName(config, info),
+		Operator:        info.operator,
+		File:            info.originalFileRelativePath,
+		Package:         getMutantPackage(info),
+		Line:            line,
+		Column:          column,
+		Diff:            string(diff),
+		Checksum:        info.checksum,
+		Outcome:         result.outcome.String(),
+		DurationSeconds: result.duration.Seconds(),
+		KilledBy:        killedBy,
+		Confidence:      result.confidence(),
+		Role:            info.role,
+		Key:             info.key,
+		Compositions:    formatCompositions(result.compositions),
+	}
+}
+
+// The import path of the mutated package, or its directory if it was not type checked
+func getMutantPackage(info MutantInfo) string {
+	if info.pkg != nil {
+		return info.pkg.Path()
+	}
+
+	return filepath.ToSlash(filepath.Dir(info.originalFileRelativePath))
+}
+
+// Unified diff between the original and the mutated file, labelled with the relative
+// path so that it doesn't change between runs. Empty if diff could not be run.
+func getMutantDiff(originalFile string, mutationFile string, relativeFile string) []byte {
+	return runDiff(exec.Command("diff", "-u", "-L", "a/"+relativeFile, "-L", "b/"+relativeFile,
+		originalFile, mutationFile), originalFile, mutationFile)
+}
+
+// Like getMutantDiff, for a mutated source which is not written to a file
+func getSourceDiff(originalFile string, source []byte, relativeFile string) []byte {
+	cmd := exec.Command("diff", "-u", "-L", "a/"+relativeFile, "-L", "b/"+relativeFile, originalFile, "-")
+	cmd.Stdin = bytes.NewReader(source)
+	return runDiff(cmd, originalFile, "-")
+}
+
+// The output of diff, which exits with 1 if the files differ
+func runDiff(cmd *exec.Cmd, originalFile string, mutationFile string) []byte {
+	diff, err := cmd.Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok || len(diff) == 0 {
+			log.WithFields(log.Fields{"original": originalFile, "mutant": mutationFile}).
+				Debug("Could not diff mutant: ", err)
+			return nil
+		}
+	}
+
+	return diff
+}
+
+// Finds the line and column (both starting at 1) of the first change in a unified diff,
+// counted in the original file. A removed line gets the column of its first non-blank character.
+func getMutationPosition(diff []byte) (line int, column int) {
+	scanner := bufio.NewScanner(bytes.NewReader(diff))
+
+	oldLine := 0
+	removed, removedLine := "", 0
+	for scanner.Scan() {
+		text := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(text, "--- ") || strings.HasPrefix(text, "+++ "):
+			continue
+		case strings.HasPrefix(text, "@@"):
+			if removedLine != 0 {
+				return removedLine, firstNonBlank(removed)
+			}
+			oldLine = getHunkStart(text)
+		case strings.HasPrefix(text, "-"):
+			if removedLine != 0 {
+				return removedLine, firstNonBlank(removed)
+			}
+			removed, removedLine = text[1:], oldLine
+			oldLine++
+		case strings.HasPrefix(text, "+"):
+			if removedLine != 0 {
+				return removedLine, firstDifference(removed, text[1:])
+			}
+			// a pure insertion goes in front of the next line of the original
+			return oldLine, firstNonBlank(text[1:])
+		default:
+			if removedLine != 0 {
+				return removedLine, firstNonBlank(removed)
+			}
+			oldLine++
+		}
+	}
+
+	if removedLine != 0 {
+		return removedLine, firstNonBlank(removed)
+	}
+
+	return 0, 0
+}
+
+// Returns the first line of the original file in a hunk header like @@ -12,7 +12,6 @@
+func getHunkStart(header string) int {
+	fields := strings.Fields(header)
+	if len(fields) < 2 {
+		return 0
+	}
+
+	start := strings.SplitN(strings.TrimPrefix(fields[1], "-"), ",", 2)[0]
+	line, err := strconv.Atoi(start)
+	if err != nil {
+		return 0
+	}
+
+	return line
+}
+
+func firstNonBlank(text string) int {
+	return len(text) - len(strings.TrimLeft(text, " \t")) + 1
+}
+
+func firstDifference(original string, mutated string) int {
+	for i := 0; i < len(original) && i < len(mutated); i++ {
+		if original[i] != mutated[i] {
+			return i + 1
+		}
+	}
+
+	if len(original) < len(mutated) {
+		return len(original) + 1
+	}
+
+	return len(mutated) + 1
+}
+
+// Writes the report of all mutants as JSON to the path
+func writeRunReport(config *MutationConfig, report *runReport) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err == nil {
+		err = afero.WriteFile(FS, config.Test.Report, data, 0644)
+	}
+	if err != nil {
+		log.WithField("path", config.Test.Report).Error(fmt.Sprintf("Could not write report: %v", err))
+		return
+	}
+
+	log.WithField("path", config.Test.Report).Info("Wrote report.")
+}
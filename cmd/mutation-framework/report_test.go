@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleDiff = `--- a/raft.go
++++ b/raft.go
+@@ -10,7 +10,7 @@
+ func (r *raft) vote() {
+ 	r.votes++
+ 
+-	if r.votes > r.quorum {
++	if true {
+ 		r.becomeLeader()
+ 	}
+ }
+`
+
+func TestGetMutationPosition(t *testing.T) {
+	line, column := getMutationPosition([]byte(sampleDiff))
+	assert.Equal(t, 13, line)
+	assert.Equal(t, 5, column)
+
+	removal := "@@ -4,3 +4,2 @@\n x := 1\n-\ty := 2\n z := 3\n"
+	line, column = getMutationPosition([]byte(removal))
+	assert.Equal(t, 5, line)
+	assert.Equal(t, 2, column)
+
+	insertion := "@@ -4,2 +4,3 @@\n x := 1\n+\t_ = x\n z := 3\n"
+	line, column = getMutationPosition([]byte(insertion))
+	assert.Equal(t, 5, line)
+	assert.Equal(t, 2, column)
+
+	line, column = getMutationPosition(nil)
+	assert.Equal(t, 0, line)
+	assert.Equal(t, 0, column)
+}
+
+func TestGetOperatorFromMutantName(t *testing.T) {
+	assert.Equal(t, "branch/if", getOperatorFromMutantName("nsqd.go.branch-if.1"))
+	assert.Equal(t, "statement/remove", getOperatorFromMutantName("blah.blag.go.statement-remove.12"))
+	assert.Equal(t, "unknown-op", getOperatorFromMutantName("nsqd.go.unknown-op.3"))
+	assert.Equal(t, "", getOperatorFromMutantName("nsqd.branch-if.1"))
+}
+
+func TestNewRunReport(t *testing.T) {
+	config := &MutationConfig{ProjectRoot: "/project/", Mutate: Mutate{MutantFolder: "mutants/"}}
+
+	mutant := func(name string, file string, operator string) MutantInfo {
+		return MutantInfo{
+			originalFileRelativePath: file,
+			mutantDirPathAbsPath:     "/project/mutants/" + name,
+			mutationFileAbsPath:      "/project/mutants/" + name + "/" + file,
+			checksum:                 name,
+			operator:                 operator,
+		}
+	}
+
+	results := []*mutantResult{
+		newMutantResult(mutant("raft/raft.go.branch-if.1", "raft/raft.go", "branch/if"),
+			&testRun{outcome: outcomeKilled, failedTests: []string{"TestElection"}, duration: 2 * time.Second}),
+		newMutantResult(mutant("raft/raft.go.statement-remove.0", "raft/raft.go", "statement/remove"),
+			&testRun{outcome: outcomeSurvived, failedTests: []string{"TestFlaky"}}),
+		newMutantResult(mutant("log.go.branch-if.0", "log.go", "branch/if"),
+			&testRun{outcome: outcomeTimedOut}),
+	}
+	allStats := map[string]*mutationStats{
+		"raft/raft.go": {passed: 1, failed: 1, duplicated: 2},
+		"log.go":       {timedOut: 1},
+	}
+
+	report := newRunReport(config, results, allStats)
+
+	assert.Equal(t, reportSchemaVersion, report.SchemaVersion)
+	assert.Equal(t, []string{"log.go.branch-if.0", "raft/raft.go.branch-if.1", "raft/raft.go.statement-remove.0"},
+		[]string{report.Mutants[0].Id, report.Mutants[1].Id, report.Mutants[2].Id})
+
+	killed := report.Mutants[1]
+	assert.Equal(t, "branch/if", killed.Operator)
+	assert.Equal(t, "raft", killed.Package)
+	assert.Equal(t, "killed", killed.Outcome)
+	assert.Equal(t, 2.0, killed.DurationSeconds)
+	assert.Equal(t, []string{"TestElection"}, killed.KilledBy)
+
+	// tests of survivors didn't kill anything
+	assert.Equal(t, []string{}, report.Mutants[2].KilledBy)
+
+	assert.Equal(t, reportScore{Killed: 1, TimedOut: 1, Total: 2, Score: 1.0}, *report.Operators["branch/if"])
+	assert.Equal(t, 0.5, report.Packages["raft"].Score)
+	assert.Equal(t, 2, report.Files["raft/raft.go"].Duplicated)
+	assert.Equal(t, reportScore{Killed: 1, Survived: 1, TimedOut: 1, Duplicated: 2, Total: 3, Score: 2.0 / 3.0}, report.Total)
+}
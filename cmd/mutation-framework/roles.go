@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/build"
+	"go/token"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// The node roles file, see node_roles.json. This is the format of compositions.Roles, which is
+// not used here since the compositions package needs libpcap.
+type nodeRoles struct {
+	Roles []nodeRole `json:"Role"`
+}
+
+type nodeRole struct {
+	Name       string        `json:"Name"`
+	Addresses  []roleAddress `json:"Address"`
+	SourceCode []roleSource  `json:"SourceCode"`
+}
+
+// Where a node of the role listens
+type roleAddress struct {
+	IP   string `json:"IP"`
+	Port string `json:"Port"`
+}
+
+// Lines of a file which belong to a role. The path is relative to the project root,
+// or an import path of the file like in the roles files of GOPATH projects.
+type roleSource struct {
+	Path      string `json:"Path"`
+	StartLine int    `json:"StartLine"`
+	EndLine   int    `json:"EndLine"`
+}
+
+// Lines of one file which belong to a role
+type roleRange struct {
+	role       string
+	start, end int
+}
+
+// Reads the roles file of the config and keeps the selected roles, nil if there is none
+func loadRoles(config *MutationConfig) (*nodeRoles, error) {
+	if config.Mutate.Roles.File == "" {
+		return nil, nil
+	}
+
+	roles, err := readRoles(config.Mutate.Roles.File)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(config.Mutate.Roles.Names) == 0 {
+		return roles, nil
+	}
+
+	selected := &nodeRoles{}
+	for _, name := range config.Mutate.Roles.Names {
+		found := false
+		for _, role := range roles.Roles {
+			if role.Name == name {
+				selected.Roles = append(selected.Roles, role)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s has no role %q", config.Mutate.Roles.File, name)
+		}
+	}
+
+	return selected, nil
+}
+
+// Reads every role of the roles file
+func readRoles(path string) (*nodeRoles, error) {
+	data, err := afero.ReadFile(FS, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var roles nodeRoles
+	err = json.Unmarshal(data, &roles)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a roles file: %v", path, err)
+	}
+
+	return &roles, nil
+}
+
+// The import path of the project root, from go.mod or GOPATH, empty if there is none
+func getProjectImportPath(projectRoot string) string {
+	data, err := afero.ReadFile(FS, appendFolder(projectRoot, "go.mod"))
+	if err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 2 && fields[0] == "module" {
+				return strings.Trim(fields[1], `"`)
+			}
+		}
+	}
+
+	importPath, _ := getGopathImportPath(projectRoot, build.Default.GOPATH)
+	return importPath
+}
+
+// The lines of the file which belong to one of the roles, in the order of the roles
+func (roles *nodeRoles) getRanges(config *MutationConfig, relativeFilePath string) []roleRange {
+	file := filepath.ToSlash(filepath.Clean(relativeFilePath))
+
+	names := map[string]struct{}{file: {}}
+	if importPath := getProjectImportPath(config.ProjectRoot); importPath != "" {
+		names[path.Join(importPath, file)] = struct{}{}
+	}
+
+	var ranges []roleRange
+	for _, role := range roles.Roles {
+		for _, source := range role.SourceCode {
+			if _, ok := names[path.Clean(source.Path)]; ok {
+				ranges = append(ranges, roleRange{role.Name, source.StartLine, source.EndLine})
+			}
+		}
+	}
+
+	return ranges
+}
+
+// The role of the first range which holds the line
+func findRole(ranges []roleRange, line int) (string, bool) {
+	for _, r := range ranges {
+		if line >= r.start && line <= r.end {
+			return r.role, true
+		}
+	}
+
+	return "", false
+}
+
+// A filter for mutesting.MutateWalkFiltered which accepts the mutations whose changed code
+// starts on a line of one of the ranges. The role of the last accepted mutation is stored
+// in role, so that it is known once the walk sends the mutation.
+func newRoleFilter(fset *token.FileSet, ranges []roleRange, role *string) func(pos token.Pos, end token.Pos) bool {
+	return func(pos token.Pos, end token.Pos) bool {
+		mutationRole, ok := findRole(ranges, fset.Position(pos).Line)
+		if ok {
+			*role = mutationRole
+		}
+
+		return ok
+	}
+}
+
+// Logs the mutation score of every role, for mutants tagged with a role
+func printRoleScores(results []*mutantResult) {
+	roles := make(map[string]*mutationStats)
+	for _, result := range results {
+		if result.mutant.role != "" {
+			recordIn(roles, result.mutant.role, result.outcome)
+		}
+	}
+
+	names := make([]string, 0, len(roles))
+	for name := range roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		stats := roles[name]
+		log.WithField("role", name).
+			Info(fmt.Sprintf("For this role, the mutation score is %f (%d passed, %d failed, %d equivalent, %d errors, %d skipped, %d timed out, %d crashed, total is %d)",
+				stats.Score(), stats.passed, stats.failed, stats.equivalent, stats.errors, stats.skipped, stats.timedOut, stats.crashed, stats.Total()))
+	}
+}
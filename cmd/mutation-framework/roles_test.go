@@ -0,0 +1,160 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/amyjzhu/mutation-framework"
+	"github.com/amyjzhu/mutation-framework/mutator"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const rolesFile = `{"Role": [
+	{"Name": "leader", "Address": [{"IP": "127.0.0.1", "Port": "8080"}],
+		"SourceCode": [{"Path": "example.com/raft/raft/leader.go", "StartLine": 0, "EndLine": 6}]},
+	{"Name": "follower",
+		"SourceCode": [{"Path": "raft/leader.go", "StartLine": 7, "EndLine": 20}, {"Path": "raft/log.go", "StartLine": 0, "EndLine": 9}]}
+]}`
+
+func TestLoadRoles(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := &MutationConfig{ProjectRoot: "/project/"}
+	roles, err := loadRoles(config)
+	assert.Nil(t, err)
+	assert.Nil(t, roles)
+
+	afero.WriteFile(FS, "/project/go.mod", []byte("module example.com/raft\n\ngo 1.20\n"), 0644)
+	afero.WriteFile(FS, "/roles.json", []byte(rolesFile), 0644)
+	config.Mutate.Roles.File = "/roles.json"
+	roles, err = loadRoles(config)
+	assert.Nil(t, err)
+	assert.Len(t, roles.Roles, 2)
+
+	// paths may be import paths or relative to the project root
+	assert.Equal(t, []roleRange{{"leader", 0, 6}, {"follower", 7, 20}}, roles.getRanges(config, "raft/leader.go"))
+	assert.Equal(t, []roleRange{{"follower", 0, 9}}, roles.getRanges(config, "raft/log.go"))
+	assert.Empty(t, roles.getRanges(config, "raft/node.go"))
+
+	config.Mutate.Roles.Names = []string{"follower"}
+	roles, err = loadRoles(config)
+	assert.Nil(t, err)
+	assert.Equal(t, []roleRange{{"follower", 7, 20}}, roles.getRanges(config, "raft/leader.go"))
+
+	config.Mutate.Roles.Names = []string{"candidate"}
+	_, err = loadRoles(config)
+	assert.Error(t, err)
+}
+
+// Parses and type checks a file without imports, like mutate does with its files
+func typeCheckSource(t *testing.T, source string) (*token.FileSet, *ast.File, *types.Package, *types.Info) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "raft.go", source, 0)
+	assert.Nil(t, err)
+
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	pkg, err := (&types.Config{}).Check("raft", fset, []*ast.File{file}, info)
+	assert.Nil(t, err)
+
+	return fset, file, pkg, info
+}
+
+// Walks the mutator over the file with the filter of the ranges and returns the role of each mutation
+func getMutationRoles(t *testing.T, source string, name string, ranges []roleRange) []string {
+	fset, file, pkg, info := typeCheckSource(t, source)
+
+	m, err := mutator.New(name)
+	assert.Nil(t, err)
+
+	var role string
+	filter := newRoleFilter(fset, ranges, &role)
+
+	var roles []string
+	changed := mutesting.MutateWalkFiltered(pkg, info, file, m, filter)
+	for {
+		if _, ok := <-changed; !ok {
+			break
+		}
+		roles = append(roles, role)
+
+		changed <- true
+		<-changed
+		changed <- true
+	}
+
+	return roles
+}
+
+func TestRoleFilter(t *testing.T) {
+	source := `package raft
+
+func lead(term int) int {
+	if term < 0 {
+		term = 0
+	}
+	return term
+}
+
+func follow(term int) int {
+	if term > 10 {
+		term = 10
+	}
+	return term
+}
+`
+
+	// only the if of follow is mutated
+	assert.Equal(t, []string{"follower"}, getMutationRoles(t, source, "branch/if", []roleRange{{"follower", 10, 15}}))
+}
+
+func TestRoleFilterInsideFunction(t *testing.T) {
+	source := `package raft
+
+func step(term int, votes int) int {
+	term++
+	votes++
+	term--
+	votes--
+	return term + votes
+}
+`
+
+	// the range starts in the middle of the body, the statements are mutated by their own lines
+	assert.Equal(t, []string{"follower", "follower"},
+		getMutationRoles(t, source, "statement/remove", []roleRange{{"follower", 6, 9}}))
+	assert.Equal(t, []string{"leader", "leader", "follower", "follower"},
+		getMutationRoles(t, source, "statement/remove", []roleRange{{"leader", 4, 5}, {"follower", 6, 7}}))
+	// a range around the opening of the body holds none of its statements
+	assert.Empty(t, getMutationRoles(t, source, "statement/remove", []roleRange{{"leader", 1, 3}}))
+}
+
+func TestRoleScores(t *testing.T) {
+	config := &MutationConfig{ProjectRoot: "/project/", Mutate: Mutate{MutantFolder: "mutants/"}}
+	mutant := func(name string, role string, outcome mutantOutcome) *mutantResult {
+		return newMutantResult(MutantInfo{originalFileRelativePath: "raft/leader.go", checksum: name,
+			mutantDirPathAbsPath: "/project/mutants/" + name, operator: "branch/if", role: role},
+			&testRun{outcome: outcome})
+	}
+
+	report := newRunReport(config, []*mutantResult{
+		mutant("a", "leader", outcomeKilled),
+		mutant("b", "leader", outcomeSurvived),
+		mutant("c", "follower", outcomeKilled),
+	}, map[string]*mutationStats{})
+
+	assert.Equal(t, 0.5, report.Roles["leader"].Score)
+	assert.Equal(t, 1.0, report.Roles["follower"].Score)
+	assert.Equal(t, "leader", report.Mutants[0].Role)
+
+	report = newRunReport(config, []*mutantResult{mutant("a", "", outcomeKilled)}, map[string]*mutationStats{})
+	assert.Nil(t, report.Roles)
+}
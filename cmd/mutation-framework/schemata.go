@@ -0,0 +1,402 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// Name of the overlay inside a mutant which is part of a schemata, see --schemata
+const schemataOverlayFile = "schemata.json"
+
+// Name of the file which is added to the package of a schemata and tells which mutant is active
+const schemataHelperFile = "mutation_schemata.go"
+
+// Environment variable which holds the checksum of the active mutant of a schemata
+const schemataEnv = "MUTATE_SCHEMATA"
+
+const schemataHelperSource = `package %s
+
+import "os"
+
+var mutationSchemataMutant = os.Getenv(%q)
+
+func mutationSchemataActive(mutant string) bool {
+	return mutant == mutationSchemataMutant
+}
+`
+
+// Weaves all mutants of a file into one source, so that the package is compiled once and
+// the mutants are switched at runtime. Each mutant replaces the innermost statement list
+// its mutation changes, and the list is guarded by a check for the active mutant.
+type schemataWeaver struct {
+	fset   *token.FileSet
+	source []byte
+	blocks map[ast.Node]*schemataBlock
+}
+
+// A statement list which mutants can be woven into
+type schemataBlock struct {
+	node ast.Node
+	// the list as printed before any mutation
+	original string
+	// offsets of the list in the source
+	start, end int
+	// lists with labels can't be duplicated, and fallthrough has to stay the last statement
+	weavable bool
+	variants []schemataVariant
+}
+
+type schemataVariant struct {
+	checksum string
+	list     string
+}
+
+func newSchemataWeaver(fset *token.FileSet, file ast.Node, source []byte) *schemataWeaver {
+	weaver := &schemataWeaver{
+		fset:   fset,
+		source: source,
+		blocks: make(map[ast.Node]*schemataBlock),
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		var start, end token.Pos
+		switch n := node.(type) {
+		case *ast.BlockStmt:
+			if isClauseList(n.List) {
+				return true
+			}
+			start, end = n.Lbrace+1, n.Rbrace
+		case *ast.CaseClause:
+			start, end = n.Colon+1, n.End()
+		case *ast.CommClause:
+			start, end = n.Colon+1, n.End()
+		default:
+			return true
+		}
+
+		list := getStatementList(node)
+		weaver.blocks[node] = &schemataBlock{
+			node:     node,
+			original: weaver.print(list),
+			start:    fset.Position(start).Offset,
+			end:      fset.Position(end).Offset,
+			weavable: !hasLabel(node) && !endsWithFallthrough(list),
+		}
+
+		return true
+	})
+
+	return weaver
+}
+
+// The statements of a block or clause
+func getStatementList(node ast.Node) []ast.Stmt {
+	switch n := node.(type) {
+	case *ast.BlockStmt:
+		return n.List
+	case *ast.CaseClause:
+		return n.Body
+	case *ast.CommClause:
+		return n.Body
+	}
+
+	return nil
+}
+
+// Whether the list is the body of a switch or select, whose clauses can't be guarded
+func isClauseList(list []ast.Stmt) bool {
+	for _, stmt := range list {
+		switch stmt.(type) {
+		case *ast.CaseClause, *ast.CommClause:
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasLabel(node ast.Node) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if _, ok := n.(*ast.LabeledStmt); ok {
+			found = true
+		}
+		return !found
+	})
+
+	return found
+}
+
+func endsWithFallthrough(list []ast.Stmt) bool {
+	if len(list) == 0 {
+		return false
+	}
+
+	branch, ok := list[len(list)-1].(*ast.BranchStmt)
+	return ok && branch.Tok == token.FALLTHROUGH
+}
+
+func (weaver *schemataWeaver) print(list []ast.Stmt) string {
+	var buf bytes.Buffer
+	for _, stmt := range list {
+		err := printer.Fprint(&buf, weaver.fset, stmt)
+		if err != nil {
+			log.WithField("error", err).Debug("Could not print statement.")
+		}
+		buf.WriteByte('\n')
+	}
+
+	return buf.String()
+}
+
+// Records the mutation which is currently applied to the file as a variant of the
+// innermost weavable list it changes. Returns false if it changes no such list,
+// e.g. because it mutates a package level declaration.
+func (weaver *schemataWeaver) addVariant(file ast.Node, checksum string) bool {
+	var changed *schemataBlock
+	var list string
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		block, ok := weaver.blocks[node]
+		if !ok {
+			return true
+		}
+
+		current := weaver.print(getStatementList(node))
+		if current == block.original {
+			// the mutation is not inside this list
+			return false
+		}
+
+		if block.weavable {
+			changed = block
+			list = current
+		}
+
+		return true
+	})
+
+	if changed == nil {
+		return false
+	}
+
+	changed.variants = append(changed.variants, schemataVariant{checksum, list})
+	return true
+}
+
+// Leaves out the variant of the mutant, e.g. because it is suppressed
+func (weaver *schemataWeaver) removeVariant(checksum string) {
+	for _, block := range weaver.blocks {
+		for i, variant := range block.variants {
+			if variant.checksum == checksum {
+				block.variants = append(block.variants[:i], block.variants[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// The source of the file with every variant woven in
+func (weaver *schemataWeaver) weave() ([]byte, error) {
+	var woven []*schemataBlock
+	for _, block := range weaver.blocks {
+		if len(block.variants) > 0 {
+			woven = append(woven, block)
+		}
+	}
+	sort.Slice(woven, func(i, j int) bool { return woven[i].start < woven[j].start })
+
+	return format.Source(weaver.render(woven, nil, 0, len(weaver.source)))
+}
+
+// Copies the source between the offsets, replacing the outermost woven lists inside
+// other than the list which is rendered itself
+func (weaver *schemataWeaver) render(woven []*schemataBlock, outer *schemataBlock, start int, end int) []byte {
+	var buf bytes.Buffer
+
+	cursor := start
+	for _, block := range woven {
+		if block == outer || block.start < cursor || block.end > end {
+			// before the range, or nested in a list which was rendered already
+			continue
+		}
+
+		buf.Write(weaver.source[cursor:block.start])
+
+		buf.WriteString("\n")
+		for i, variant := range block.variants {
+			if i > 0 {
+				buf.WriteString(" else ")
+			}
+			fmt.Fprintf(&buf, "if mutationSchemataActive(%q) {\n%s}", variant.checksum, variant.list)
+		}
+		buf.WriteString(" else {\n")
+		buf.Write(bytes.TrimSpace(weaver.render(woven, block, block.start, block.end)))
+		buf.WriteString("\n}\n")
+
+		cursor = block.end
+	}
+	buf.Write(weaver.source[cursor:end])
+
+	return buf.Bytes()
+}
+
+func getSchemataDir(config *MutationConfig, relativeFilePath string) string {
+	return filepath.Clean(appendFolder(getAbsoluteMutationFolderPath(config), relativeFilePath+".schemata"))
+}
+
+func getSchemataOverlayPath(mutantDir string) string {
+	return appendFolder(mutantDir, schemataOverlayFile)
+}
+
+// Writes the schemata of a file and points the mutants which are woven into it at it.
+// If the woven package does not compile, the mutants are tested on their own.
+func writeSchemata(config *MutationConfig, weaver *schemataWeaver, relativeFilePath string,
+	packageName string, mutants []MutantInfo) error {
+	schemataDir := getSchemataDir(config, relativeFilePath)
+	err := FS.RemoveAll(schemataDir)
+	if err != nil {
+		return err
+	}
+
+	woven, err := weaver.weave()
+	if err != nil {
+		return fmt.Errorf("could not weave mutants: %v", err)
+	}
+
+	wovenPath := filepath.Join(schemataDir, relativeFilePath)
+	helperPath := filepath.Join(filepath.Dir(wovenPath), schemataHelperFile)
+
+	err = FS.MkdirAll(filepath.Dir(wovenPath), 0755)
+	if err != nil {
+		return err
+	}
+	// like overlay mutants, the folder must not be part of ./... of the project
+	err = afero.WriteFile(FS, appendFolder(schemataDir, "go.mod"), []byte("module mutant\n"), 0644)
+	if err != nil {
+		return err
+	}
+	err = afero.WriteFile(FS, wovenPath, woven, 0644)
+	if err != nil {
+		return err
+	}
+	err = afero.WriteFile(FS, helperPath, []byte(fmt.Sprintf(schemataHelperSource, packageName, schemataEnv)), 0644)
+	if err != nil {
+		return err
+	}
+
+	overlay, err := getSchemataOverlay(config, relativeFilePath)
+	if err != nil {
+		return err
+	}
+	err = afero.WriteFile(FS, getOverlayPath(schemataDir), overlay, 0644)
+	if err != nil {
+		return err
+	}
+
+	err = buildSchemata(config, getOverlayPath(schemataDir), relativeFilePath)
+	if err != nil {
+		log.WithFields(log.Fields{"file": relativeFilePath, "error": err}).
+			Warn("Schemata does not compile, testing the mutants of the file on their own.")
+		return nil
+	}
+
+	wovenMutants := make(map[string]struct{})
+	for _, block := range weaver.blocks {
+		for _, variant := range block.variants {
+			wovenMutants[variant.checksum] = struct{}{}
+		}
+	}
+
+	for _, mutant := range mutants {
+		if _, ok := wovenMutants[mutant.checksum]; !ok {
+			continue
+		}
+
+		err = afero.WriteFile(FS, getSchemataOverlayPath(mutant.mutantDirPathAbsPath), overlay, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.WithFields(log.Fields{"file": relativeFilePath, "schemata": wovenPath, "mutants": len(wovenMutants)}).
+		Info("Wove mutants into schemata.")
+	return nil
+}
+
+// The overlay which replaces the file by its schemata and adds the helper to its package
+func getSchemataOverlay(config *MutationConfig, relativeFilePath string) ([]byte, error) {
+	original, err := filepath.Abs(concatAddingSlashIfNeeded(config.ProjectRoot, relativeFilePath))
+	if err != nil {
+		return nil, err
+	}
+	wovenPath := filepath.Join(getSchemataDir(config, relativeFilePath), relativeFilePath)
+	helperPath := filepath.Join(filepath.Dir(wovenPath), schemataHelperFile)
+
+	return json.MarshalIndent(buildOverlay{map[string]string{
+		original: wovenPath,
+		filepath.Join(filepath.Dir(original), schemataHelperFile): helperPath,
+	}}, "", "  ")
+}
+
+// Compiles the package of the file with the schemata
+func buildSchemata(config *MutationConfig, overlay string, relativeFilePath string) error {
+	workspace, err := newProjectWorkspace(config)
+	if err != nil {
+		return err
+	}
+	workspace.timeout = config.Test.getTimeout()
+
+	result, err := workspace.run(workspace.command("go", "build", "-o", os.DevNull, "-overlay", overlay,
+		"."+string(os.PathSeparator)+filepath.Dir(relativeFilePath)))
+	if err != nil {
+		return err
+	}
+	if result.timedOut || result.exitCode != 0 || result.signaled {
+		return fmt.Errorf("go build failed with exit code %d: %s", result.exitCode, result.output)
+	}
+
+	return nil
+}
+
+// Sets up the workspace of a mutant which is woven into a schemata, which runs like an
+// overlay mutant with the schemata as overlay and the mutant switched on. Mutants
+// which could not be woven run as overlay mutants. Like the overlay of an overlay mutant,
+// the overlay of the schemata is written again.
+func newSchemataWorkspace(config *MutationConfig, mutantInfo MutantInfo) (*mutantWorkspace, error) {
+	workspace, err := newOverlayWorkspace(config, mutantInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	overlay := getSchemataOverlayPath(mutantInfo.mutantDirPathAbsPath)
+	if _, err := FS.Stat(overlay); err != nil {
+		return workspace, nil
+	}
+
+	data, err := getSchemataOverlay(config, mutantInfo.originalFileRelativePath)
+	if err != nil {
+		return nil, err
+	}
+	err = afero.WriteFile(FS, overlay, data, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	workspace.overlay = overlay
+	workspace.env = setEnv(workspace.env, "MUTATE_OVERLAY", overlay)
+	workspace.env = setEnv(workspace.env, schemataEnv, mutantInfo.checksum)
+
+	return workspace, nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const schemataSource = `package calc
+
+var limit = 1 + 2
+
+// Abs returns the absolute value
+func Abs(n int) int {
+	if n < 0 {
+		n = -n // negate
+	}
+	return n
+}
+
+func Count(n int) int {
+	count := 0
+outer:
+	for i := 0; i < n; i++ {
+		count++
+		if i > limit {
+			continue outer
+		}
+	}
+	return count
+}
+`
+
+func TestWeaveSchemata(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "calc.go", schemataSource, parser.ParseComments)
+	assert.Nil(t, err)
+	weaver := newSchemataWeaver(fset, file, []byte(schemataSource))
+
+	abs := file.Decls[1].(*ast.FuncDecl).Body
+	ifBody := abs.List[0].(*ast.IfStmt).Body
+	count := file.Decls[2].(*ast.FuncDecl).Body
+	loop := count.List[1].(*ast.LabeledStmt).Stmt.(*ast.ForStmt).Body
+	noop := &ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent("_")}, Tok: token.ASSIGN, Rhs: []ast.Expr{ast.NewIdent("n")}}
+
+	// each mutation is woven into the innermost list it changes
+	old := ifBody.List[0]
+	ifBody.List[0] = noop
+	assert.True(t, weaver.addVariant(file, "first"))
+	ifBody.List[0] = old
+
+	old = loop.List[0]
+	loop.List[0] = &ast.EmptyStmt{}
+	assert.True(t, weaver.addVariant(file, "second"))
+	loop.List[0] = old
+
+	// the list holding a label can't be duplicated
+	old = count.List[0]
+	count.List[0] = &ast.EmptyStmt{}
+	assert.False(t, weaver.addVariant(file, "third"))
+	count.List[0] = old
+
+	// package level declarations are not in any list
+	spec := file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.ValueSpec)
+	value := spec.Values[0]
+	spec.Values[0] = ast.NewIdent("1")
+	assert.False(t, weaver.addVariant(file, "fourth"))
+	spec.Values[0] = value
+
+	woven, err := weaver.weave()
+	assert.Nil(t, err)
+
+	_, err = parser.ParseFile(token.NewFileSet(), "calc.go", woven, parser.ParseComments)
+	assert.Nil(t, err)
+	assert.Contains(t, string(woven), "if mutationSchemataActive(\"first\") {\n\t\t\t_ = n\n\t\t} else {\n\t\t\tn = -n // negate\n\t\t}")
+	assert.Contains(t, string(woven), "if mutationSchemataActive(\"second\") {")
+	assert.NotContains(t, string(woven), "third")
+	assert.Contains(t, string(woven), "var limit = 1 + 2")
+	assert.Contains(t, string(woven), "// Abs returns the absolute value")
+}
+
+func TestSchemataSwitchesMutantsAtRuntime(t *testing.T) {
+	root, err := ioutil.TempDir("", "schemata")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+
+	source := "package main\n\nimport \"fmt\"\n\nfunc abs(n int) int {\n\tif n < 0 {\n\t\tn = -n\n\t}\n\treturn n\n}\n\n" +
+		"func main() {\n\tfmt.Println(abs(-3))\n}\n"
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "calc"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/calc\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(root, "calc", "calc.go"), []byte(source), 0644))
+
+	config := &MutationConfig{ProjectRoot: root + "/"}
+	config.Mutate.MutantFolder = "mutants/"
+	config.Mutate.Schemata = true
+	config.Test.Timeout = 60
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "calc.go", source, parser.ParseComments)
+	assert.Nil(t, err)
+	weaver := newSchemataWeaver(fset, file, []byte(source))
+
+	abs := file.Decls[1].(*ast.FuncDecl).Body
+	ifBody := abs.List[0].(*ast.IfStmt).Body
+
+	// doesn't negate, so abs(-3) is -3
+	old := ifBody.List[0]
+	ifBody.List[0] = &ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent("_")}, Tok: token.ASSIGN, Rhs: []ast.Expr{ast.NewIdent("n")}}
+	assert.True(t, weaver.addVariant(file, "first"))
+	ifBody.List[0] = old
+
+	// one too many, so abs(-3) is 4
+	old = abs.List[1]
+	abs.List[1] = &ast.ReturnStmt{Results: []ast.Expr{&ast.BinaryExpr{X: ast.NewIdent("n"), Op: token.ADD,
+		Y: &ast.BasicLit{Kind: token.INT, Value: "1"}}}}
+	assert.True(t, weaver.addVariant(file, "second"))
+	abs.List[1] = old
+
+	var mutants []MutantInfo
+	for _, checksum := range []string{"first", "second"} {
+		mutantDir := filepath.Join(root, "mutants", "calc", "calc.go."+checksum)
+		assert.Nil(t, os.MkdirAll(mutantDir, 0755))
+		mutants = append(mutants, MutantInfo{originalFileRelativePath: "calc/calc.go", mutantDirPathAbsPath: mutantDir,
+			checksum: checksum})
+	}
+	assert.Nil(t, writeSchemata(config, weaver, "calc/calc.go", "main", mutants))
+
+	// every mutant runs the same woven package, the variable picks the mutant
+	overlay := getSchemataOverlayPath(mutants[0].mutantDirPathAbsPath)
+	_, err = os.Stat(getSchemataOverlayPath(mutants[1].mutantDirPathAbsPath))
+	assert.Nil(t, err)
+	run := func(mutant string) string {
+		cmd := exec.Command("go", "run", "-overlay", overlay, "./calc")
+		cmd.Dir = root
+		cmd.Env = append(os.Environ(), schemataEnv+"="+mutant)
+		output, err := cmd.CombinedOutput()
+		assert.Nil(t, err, string(output))
+
+		return strings.TrimSpace(string(output))
+	}
+
+	assert.Equal(t, "3", run(""))
+	assert.Equal(t, "-3", run("first"))
+	assert.Equal(t, "4", run("second"))
+}
+
+func TestSchemataWorkspace(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := &MutationConfig{ProjectRoot: "/project/"}
+	config.Mutate.MutantFolder = "mutants/"
+	config.Mutate.Schemata = true
+
+	mutantDir, err := createOverlayMutant(config, "calc/calc.go.branch-if.0", "calc/calc.go")
+	assert.Nil(t, err)
+	mutant := MutantInfo{originalFileRelativePath: "calc/calc.go", mutantDirPathAbsPath: mutantDir,
+		mutationFileAbsPath: mutantDir + "/calc/calc.go", checksum: "620e3926e2728dfab89cce7a24a64e15"}
+
+	// mutants which were not woven run as overlay mutants
+	workspace, err := newMutantWorkspace(config, mutant)
+	assert.Nil(t, err)
+	assert.Equal(t, getOverlayPath(mutantDir), workspace.overlay)
+
+	afero.WriteFile(FS, getSchemataOverlayPath(mutantDir), []byte(`{"Replace": {}}`), 0644)
+	workspace, err = newMutantWorkspace(config, mutant)
+	assert.Nil(t, err)
+	assert.Equal(t, getSchemataOverlayPath(mutantDir), workspace.overlay)
+	assert.Contains(t, workspace.env, "MUTATE_SCHEMATA=620e3926e2728dfab89cce7a24a64e15")
+
+	// the overlay is written again for the project root and the mutant folder of the run
+	data, _ := afero.ReadFile(FS, getSchemataOverlayPath(mutantDir))
+	var overlay buildOverlay
+	assert.Nil(t, json.Unmarshal(data, &overlay))
+	assert.Equal(t, map[string]string{
+		"/project/calc/calc.go":               "/project/mutants/calc/calc.go.schemata/calc/calc.go",
+		"/project/calc/" + schemataHelperFile: "/project/mutants/calc/calc.go.schemata/calc/" + schemataHelperFile,
+	}, overlay.Replace)
+
+	// the schemata of an earlier run is gone once the mutant is overwritten
+	config.Mutate.Overwrite = true
+	_, err = createOverlayMutant(config, "calc/calc.go.branch-if.0", "calc/calc.go")
+	assert.Nil(t, err)
+	exists, _ := afero.Exists(FS, getSchemataOverlayPath(mutantDir))
+	assert.False(t, exists)
+}
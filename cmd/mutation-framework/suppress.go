@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// Mutants which should not be created, e.g. because they are equivalent to the original
+// Each entry names a mutant by its checksum or by its key from the report, and says why.
+type suppressionList struct {
+	Suppressions []suppression `json:"suppressions"`
+}
+
+type suppression struct {
+	Checksum string `json:"checksum,omitempty"`
+	// a key as in the report, the #occurrence suffix may be left out to match all of them
+	Key    string `json:"key,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Reads the suppression file of the config, nil if there is none
+func loadSuppressions(config *MutationConfig) (*suppressionList, error) {
+	if config.Mutate.Suppressions == "" {
+		return nil, nil
+	}
+
+	data, err := afero.ReadFile(FS, config.Mutate.Suppressions)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isJson(data) {
+		data, err = convertFromYaml(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var list suppressionList
+	err = json.Unmarshal(data, &list)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a suppression file: %v", config.Mutate.Suppressions, err)
+	}
+
+	for i, entry := range list.Suppressions {
+		if (entry.Checksum == "") == (entry.Key == "") {
+			return nil, fmt.Errorf("suppression %d needs either a checksum or a key", i+1)
+		}
+		if strings.TrimSpace(entry.Reason) == "" {
+			return nil, fmt.Errorf("suppression %d needs a reason", i+1)
+		}
+	}
+
+	log.WithFields(log.Fields{"path": config.Mutate.Suppressions, "suppressions": len(list.Suppressions)}).
+		Info("Loaded suppressed mutants.")
+	return &list, nil
+}
+
+// The suppression of a mutant with the checksum and the stable key, if there is one
+func (list *suppressionList) find(checksum string, key string) (suppression, bool) {
+	if list == nil {
+		return suppression{}, false
+	}
+
+	keyWithoutOccurrence := key
+	if i := strings.LastIndex(key, "#"); i >= 0 {
+		keyWithoutOccurrence = key[:i]
+	}
+	for _, entry := range list.Suppressions {
+		if entry.Checksum != "" && entry.Checksum == checksum {
+			return entry, true
+		}
+		if entry.Key != "" && (entry.Key == key || entry.Key == keyWithoutOccurrence) {
+			return entry, true
+		}
+	}
+
+	return suppression{}, false
+}
+
+// Gives the mutants of a file the keys of the report. They are numbered over all mutants
+// that are not duplicates, suppressed or not, so a suppression by key matches the key in the report.
+// The diffs are of the printed sources, since the mutants are not created yet.
+func assignStableKeys(config *MutationConfig, mutants []plannedMutant) {
+	keyed := make([]reportMutant, len(mutants))
+	for i, mutant := range mutants {
+		info := mutant.info
+		originalFilePath := concatAddingSlashIfNeeded(config.ProjectRoot, info.originalFileRelativePath)
+		diff := getSourceDiff(originalFilePath, mutant.source, info.originalFileRelativePath)
+		line, column := getMutationPosition(diff)
+		keyed[i] = reportMutant{Id: getMutantName(config, info), Operator: info.operator,
+			File: info.originalFileRelativePath, Line: line, Column: column, Diff: string(diff)}
+	}
+	setStableKeys(keyed)
+
+	for i := range mutants {
+		mutants[i].info.key = keyed[i].Key
+	}
+}
+
+// Splits the mutants into the ones which are created and executed and the suppressed ones
+func removeSuppressed(list *suppressionList, mutants []plannedMutant) (kept []plannedMutant, suppressed []plannedMutant) {
+	for _, mutant := range mutants {
+		info := mutant.info
+		entry, ok := list.find(info.checksum, info.key)
+		if !ok {
+			kept = append(kept, mutant)
+			continue
+		}
+
+		log.WithFields(log.Fields{"name": mutant.name, "checksum": info.checksum,
+			"key": info.key, "reason": entry.Reason}).Info("Mutant is suppressed.")
+		suppressed = append(suppressed, mutant)
+	}
+
+	return kept, suppressed
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSuppressions(t *testing.T) {
+	FS = afero.NewMemMapFs()
+	defer func() { FS = afero.NewOsFs() }()
+
+	config := &MutationConfig{}
+	list, err := loadSuppressions(config)
+	assert.Nil(t, err)
+	assert.Nil(t, list)
+
+	config.Mutate.Suppressions = "/suppressions.json"
+	afero.WriteFile(FS, config.Mutate.Suppressions, []byte(`{"suppressions": [
+		{"checksum": "5b1ca0cfedd786d9df136a0e042df23a", "reason": "equivalent, the early exit is an optimization"},
+		{"key": "raft/log.go:branch/if:0a1b2c3d4e5f", "reason": "equivalent"}
+	]}`), 0644)
+	list, err = loadSuppressions(config)
+	assert.Nil(t, err)
+	assert.Len(t, list.Suppressions, 2)
+
+	afero.WriteFile(FS, config.Mutate.Suppressions, []byte("suppressions:\n- key: raft/log.go:branch/if:0a1b2c3d4e5f#2\n  reason: logging only\n"), 0644)
+	list, err = loadSuppressions(config)
+	assert.Nil(t, err)
+	assert.Equal(t, "logging only", list.Suppressions[0].Reason)
+
+	for _, invalid := range []string{
+		`{"suppressions": [{"checksum": "5b1ca0cfedd786d9df136a0e042df23a"}]}`,
+		`{"suppressions": [{"reason": "equivalent"}]}`,
+		`{"suppressions": [{"checksum": "5b1c", "key": "raft/log.go:branch/if:0a1b2c3d4e5f", "reason": "equivalent"}]}`,
+	} {
+		afero.WriteFile(FS, config.Mutate.Suppressions, []byte(invalid), 0644)
+		_, err = loadSuppressions(config)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestFindSuppression(t *testing.T) {
+	list := &suppressionList{[]suppression{
+		{Checksum: "abc", Reason: "equivalent"},
+		{Key: "calc.go:branch/if:0a1b2c3d4e5f", Reason: "all of them"},
+		{Key: "calc.go:statement/remove:0a1b2c3d4e5f#2", Reason: "only the second"},
+	}}
+
+	entry, ok := list.find("abc", "other.go:branch/if:ffffffffffff#1")
+	assert.True(t, ok)
+	assert.Equal(t, "equivalent", entry.Reason)
+
+	_, ok = list.find("def", "calc.go:branch/if:0a1b2c3d4e5f#3")
+	assert.True(t, ok)
+
+	_, ok = list.find("def", "calc.go:statement/remove:0a1b2c3d4e5f#1")
+	assert.False(t, ok)
+	entry, ok = list.find("def", "calc.go:statement/remove:0a1b2c3d4e5f#2")
+	assert.True(t, ok)
+	assert.Equal(t, "only the second", entry.Reason)
+
+	var none *suppressionList
+	_, ok = none.find("abc", "calc.go:branch/if:0a1b2c3d4e5f#1")
+	assert.False(t, ok)
+}
+
+func TestSuppressionKeysAreReportKeys(t *testing.T) {
+	root, err := ioutil.TempDir("", "mutation-testing")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+
+	config := &MutationConfig{ProjectRoot: root + "/", Mutate: Mutate{MutantFolder: "mutants/"}}
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(root, "calc.go"),
+		[]byte("package calc\n\nfunc twice(x int) int {\n\tx++\n\tx *= 2\n\tx++\n\treturn x\n}\n"), 0644))
+
+	// both mutants remove one of the increments, so they only differ in their position
+	mutant := func(name string, checksum string, body string) plannedMutant {
+		dir := filepath.Join(root, "mutants", name)
+		return plannedMutant{name, MutantInfo{originalFileRelativePath: "calc.go", mutantDirPathAbsPath: dir,
+			mutationFileAbsPath: filepath.Join(dir, "calc.go"), checksum: checksum, operator: "statement/remove"},
+			[]byte("package calc\n\nfunc twice(x int) int {\n" + body + "\treturn x\n}\n")}
+	}
+
+	// printed in another order than the one of their lines
+	mutants := []plannedMutant{mutant("calc.go.statement-remove.0", "abc", "\tx++\n\tx *= 2\n"),
+		mutant("calc.go.statement-remove.1", "def", "\tx *= 2\n\tx++\n")}
+	assignStableKeys(config, mutants)
+	assert.True(t, strings.HasSuffix(mutants[0].info.key, "#2"), mutants[0].info.key)
+	assert.True(t, strings.HasSuffix(mutants[1].info.key, "#1"), mutants[1].info.key)
+
+	kept, suppressed := removeSuppressed(&suppressionList{[]suppression{{Key: mutants[1].info.key, Reason: "equivalent"}}}, mutants)
+	assert.Equal(t, []plannedMutant{mutants[1]}, suppressed)
+
+	// the report keeps the numbering of all mutants, with the file of the kept one written
+	assert.Nil(t, os.MkdirAll(kept[0].info.mutantDirPathAbsPath, 0755))
+	assert.Nil(t, writeMutantFile(kept[0].info.mutationFileAbsPath, kept[0].source))
+	report := newRunReport(config, []*mutantResult{newMutantResult(kept[0].info, &testRun{outcome: outcomeKilled})},
+		map[string]*mutationStats{})
+	assert.Equal(t, mutants[0].info.key, report.Mutants[0].Key)
+	assert.Equal(t, getMutantDiff(filepath.Join(root, "calc.go"), kept[0].info.mutationFileAbsPath, "calc.go"),
+		getSourceDiff(filepath.Join(root, "calc.go"), kept[0].source, "calc.go"))
+}
+
+// Records the folders that are created
+type mkdirRecordingFs struct {
+	afero.Fs
+	created []string
+}
+
+func (fs *mkdirRecordingFs) MkdirAll(path string, perm os.FileMode) error {
+	fs.created = append(fs.created, path)
+	return fs.Fs.MkdirAll(path, perm)
+}
+
+func TestSuppressedMutantsAreNotCreated(t *testing.T) {
+	root, err := ioutil.TempDir("", "mutation-testing")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+
+	file := filepath.Join(root, "calc.go")
+	assert.Nil(t, ioutil.WriteFile(file,
+		[]byte("package calc\n\nfunc twice(x int) int {\n\tx++\n\tx *= 2\n\treturn x\n}\n"), 0644))
+	config, err := parseAndValidateConfig([]byte(`{"project_root":"` + root + `/","mutate":{"operators":["statement/remove"],` +
+		`"overlay":true,"mutant_folder":"` + root + `/all/"}}`))
+	assert.Nil(t, err)
+
+	_, all, exitCode := mutateFiles(config, map[string]string{"calc.go": file})
+	assert.Equal(t, returnOk, exitCode)
+	assert.Len(t, all, 2)
+
+	config.Mutate.Suppressions = filepath.Join(root, "suppressions.json")
+	assert.Nil(t, ioutil.WriteFile(config.Mutate.Suppressions,
+		[]byte(`{"suppressions":[{"key":"`+all[0].key+`","reason":"equivalent"}]}`), 0644))
+	config.Mutate.MutantFolder = root + "/suppressed/"
+
+	fs := &mkdirRecordingFs{Fs: afero.NewOsFs()}
+	FS = fs
+	defer func() { FS = afero.NewOsFs() }()
+
+	allStats, kept, exitCode := mutateFiles(config, map[string]string{"calc.go": file})
+	assert.Equal(t, returnOk, exitCode)
+	assert.Len(t, kept, 1)
+	assert.Equal(t, all[1].key, kept[0].key)
+	assert.Equal(t, 1, allStats["calc.go"].suppressed)
+
+	suppressedName := getMutantName(&MutationConfig{Mutate: Mutate{MutantFolder: root + "/all/"}}, all[0])
+	for _, dir := range fs.created {
+		assert.False(t, strings.HasPrefix(dir, filepath.Join(root, "suppressed", suppressedName)), dir)
+	}
+	assert.Contains(t, fs.created, filepath.Dir(kept[0].mutationFileAbsPath))
+}
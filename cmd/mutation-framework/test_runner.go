@@ -2,18 +2,19 @@ package main
 
 import (
 	"fmt"
-	"go/types"
 	"os/exec"
 	"syscall"
 	"strings"
 	"regexp"
 	"os"
 	"sort"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 	"path/filepath"
 	"crypto/md5"
 	"github.com/amyjzhu/mutation-framework"
+	"github.com/amyjzhu/mutation-framework/mutator"
 	"github.com/spf13/afero"
 )
 
@@ -21,15 +22,14 @@ import (
 // TODO count statistics per mutant
 func printStats(config *MutationConfig, allStats map[string]*mutationStats) {
 	if !config.Test.Disable {
-		getRedundantCandidates()
 		log.Info("Mutants killed by: ", testsToMutants)
 		log.Info("Live mutants are: ", liveMutants)
 
 		// print stats for each file
 		for file, stats := range allStats {
 			log.WithField("file", file).
-				Info(fmt.Sprintf("For this file, the mutation score is %f (%d passed, %d failed, %d duplicated, %d skipped, total is %d)",
-					stats.Score(), stats.passed, stats.failed, stats.duplicated, stats.skipped, stats.Total()))
+				Info(fmt.Sprintf("For this file, the mutation score is %f (%d passed, %d failed, %d duplicated, %d suppressed, %d equivalent, %d errors, %d skipped, %d timed out, %d crashed, total is %d)",
+					stats.Score(), stats.passed, stats.failed, stats.duplicated, stats.suppressed, stats.equivalent, stats.errors, stats.skipped, stats.timedOut, stats.crashed, stats.Total()))
 		}
 	} else {
 		log.Info("Cannot do a mutation testing summary since no exec command was executed.")
@@ -40,6 +40,9 @@ func printStats(config *MutationConfig, allStats map[string]*mutationStats) {
 // TODO doesn;t work for some reason?
 var liveMutants = make([]string, 0)
 
+// Guards the results shared between workers, i.e. liveMutants, testsToMutants and the mutationStats
+var resultsLock sync.Mutex
+
 func findAllMutantsInFolder(config *MutationConfig, allStats map[string]*mutationStats, filesToExec map[string]string) ([]MutantInfo, error) {
 	log.Info("Finding mutants and mutant files.")
 	var mutants []MutantInfo
@@ -122,10 +125,28 @@ func createNewMutantInfo(acceptableFiles map[string]string, pathSoFar string, fi
 
 	log.WithField("path", mutatedFileAbsolutePath).Debug("Found mutant.")
 	mutantInfo := MutantInfo{pkg, originalFilePath,
-		currentPath, mutatedFileAbsolutePath, checksum}
+		currentPath, mutatedFileAbsolutePath, checksum,
+		getOperatorFromMutantName(fileInfo.Name()), "", ""}
 	return &mutantInfo, nil
 }
 
+// Recovers the operator from a mutant folder built by buildMutantName, e.g. branch/if from nsqd.go.branch-if.1
+func getOperatorFromMutantName(mutantFolder string) string {
+	mutantNamePattern := regexp.MustCompile(`^[\w\-. ]+?\.go\.([\w\-. ]+)\.[\d]+$`)
+	match := mutantNamePattern.FindStringSubmatch(filepath.Clean(mutantFolder))
+	if match == nil {
+		return ""
+	}
+
+	for _, name := range mutator.List() {
+		if strings.Replace(name, "/", "-", -1) == match[1] {
+			return name
+		}
+	}
+
+	return match[1]
+}
+
 // TODO replace with path.Join
 func appendFolder(original string, folder string) string {
 	if original == "" || original == "."{
@@ -158,87 +179,157 @@ func executeAllMutants(config *MutationConfig, mutantFiles []MutantInfo, allStat
 	log.Info("Executing tests against mutants.")
 	exitCode := returnOk
 
-	// move all contents into temp file
-	// maybe in the future can use go move
-	mutantFolder := config.Mutate.MutantFolder
-	//mutantFolder := appendFolder(config.ProjectRoot, config.Mutate.MutantFolder)
-	originalArtifactFolder := appendFolder(mutantFolder, "original")
-	err := moveAllContentsExceptMutantFolder(".", originalArtifactFolder, mutantFolder)
+	journal, err := openRunJournal(config)
 	if err != nil {
-		log.Error(err)
+		log.WithError(err).Error("Could not open the journal.")
 		return returnError
 	}
+	cache := newResultCache(config)
+	equivalence := newEquivalenceDetector(config)
 
-	// TODO actually replace back with original rather than symlink, but oh well
-	defer func() int {
-		/*err = os.Symlink(".", originalArtifactFolder)
-		if err != nil {
-			return returnError
-		}
-		return returnOk*/
+	log.WithField("workers", config.Test.getWorkers()).Info("Starting workers.")
+	var results []*mutantResult
+	runWorkerPool(config.Test.getWorkers(), mutantFiles, func(file MutantInfo) {
+		stats := allStats[file.originalFileRelativePath]
+		result := executeForMutant(config, file, stats, cache, journal, equivalence)
 
-		moveAllContentsExceptMutantFolder(originalArtifactFolder, ".", mutantFolder)
-		if err != nil {
-			log.Error(err)
-			return returnError
-		}
-		// remove original
-		os.RemoveAll(originalArtifactFolder)
-		return returnOk
-	}()
+		resultsLock.Lock()
+		results = append(results, result)
+		resultsLock.Unlock()
+	})
 
-	for _, file := range mutantFiles {
-		stats := allStats[file.originalFileRelativePath]
-		//moveAllContentsExceptMutantFolder(file.mutantDirPathAbsPath, ".", mutantFolder)
-		os.Symlink(".", file.mutantDirPathAbsPath)
-		if err != nil {
-			log.Error(err)
-			return returnError
-		}
-		exitCode = executeForMutant(config, file, stats)
+	cache.save()
+	printStats(config, allStats)
+	reportKillMatrix(config, results)
+	writeReports(config, results, allStats)
+	if config.Mutate.Roles.File != "" {
+		printRoleScores(results)
+	}
+	if config.Test.reportsCompositions() {
+		printCompositionMatrix(config, results)
+	}
+	if config.Test.RerunSurvivors {
+		printConfidence(results)
 	}
 
-	printStats(config, allStats)
+	exitCode = enforceThresholds(config, results, allStats)
 	return exitCode
 }
 
-// Run an execution for one mutant
-func executeForMutant(config *MutationConfig, mutantInfo MutantInfo, stats *mutationStats) int {
-	log.WithField("mutant", mutantInfo.mutationFileAbsPath).Debug("Running tests.")
-
-	if !config.Test.Disable {
-		execExitCode := runTestsForMutant(config, mutantInfo.pkg,
-			mutantInfo.originalFileRelativePath, mutantInfo.mutantDirPathAbsPath,
-			mutantInfo.mutationFileAbsPath)
-
-		log.WithField("exit_code", execExitCode).Debug("Finished running tests.")
+// Writes the reports that are configured
+func writeReports(config *MutationConfig, results []*mutantResult, allStats map[string]*mutationStats) {
+	if config.Test.Report == "" && config.Test.HtmlReport == "" &&
+		config.Test.JunitReport == "" && config.Test.ElementsReport == "" {
+		return
+	}
 
-		msg := fmt.Sprintf("%q with checksum %s", mutantInfo.mutationFileAbsPath, mutantInfo.checksum)
+	report := newRunReport(config, results, allStats)
+	if config.Test.Report != "" {
+		writeRunReport(config, report)
+	}
+	if config.Test.HtmlReport != "" {
+		writeHtmlReport(config, report)
+	}
+	if config.Test.JunitReport != "" {
+		writeJunitReport(config, report)
+	}
+	if config.Test.ElementsReport != "" {
+		writeElementsReport(config, report)
+	}
+}
 
-		switch execExitCode {
-		case execPassed:
-			log.Info(fmt.Sprintf("PASS %s", msg))
+// Hands every mutant to one of the workers and waits until all of them are done
+// Each worker executes one mutant at a time
+func runWorkerPool(workers int, mutantFiles []MutantInfo, execute func(MutantInfo)) {
+	mutants := make(chan MutantInfo)
+	var wg sync.WaitGroup
+
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+
+			for mutant := range mutants {
+				log.WithFields(log.Fields{"worker": worker, "mutant": mutant.mutantDirPathAbsPath}).
+					Debug("Worker picked up mutant.")
+				execute(mutant)
+			}
+		}(worker)
+	}
 
-			stats.passed++
-		case execFailed:
-			log.Info(fmt.Sprintf("FAIL %s", msg))
+	for _, mutant := range mutantFiles {
+		mutants <- mutant
+	}
+	close(mutants)
 
-			stats.failed++
-		case execSkipped:
-			log.Info(fmt.Sprintf("SKIP %s", msg))
+	wg.Wait()
+}
 
-			stats.skipped++
-		default:
-			log.Info(fmt.Sprintf("UNKNOWN exit code for %s", msg))
+// Run an execution for one mutant, unless the resumed run or the cache knows its verdict
+// or it compiles to the same code as the original
+func executeForMutant(config *MutationConfig, mutantInfo MutantInfo, stats *mutationStats,
+	cache *resultCache, journal *runJournal, equivalence *equivalenceDetector) *mutantResult {
+	result, ok := journal.lookup(config, mutantInfo)
+	if ok {
+		log.WithField("mutant", mutantInfo.mutationFileAbsPath).Debug("Using result of resumed run.")
+		// the interrupted run may not have saved the cache, and its entry must not be pruned
+		cache.store(result)
+	} else {
+		if run, ok := cache.lookup(mutantInfo); ok {
+			log.WithField("mutant", mutantInfo.mutationFileAbsPath).Debug("Using cached result.")
+			result = newMutantResult(mutantInfo, run)
+		} else if equivalence.isEquivalent(config, mutantInfo) {
+			log.WithField("mutant", mutantInfo.mutationFileAbsPath).Debug("Mutant compiles to the same code as the original.")
+			result = newMutantResult(mutantInfo, &testRun{outcome: outcomeEquivalent})
+			cache.store(result)
+		} else {
+			log.WithField("mutant", mutantInfo.mutationFileAbsPath).Debug("Running tests.")
+
+			result = newMutantResult(mutantInfo, runTestsForMutant(config, mutantInfo))
+			if config.Test.RerunSurvivors {
+				confirmVerdict(config, result)
+			}
+			cache.store(result)
 		}
+		journal.record(config, result)
+	}
 
-		return execExitCode
+	outcome := result.outcome
+	log.WithFields(log.Fields{"outcome": outcome.String(), "confidence": result.confidence()}).
+		Debug("Finished running tests.")
+
+	msg := fmt.Sprintf("%q with checksum %s", mutantInfo.mutationFileAbsPath, mutantInfo.checksum)
+
+	putFailedTestsInMap(mutantInfo.mutationFileAbsPath, result.failedTests)
+
+	resultsLock.Lock()
+	defer resultsLock.Unlock()
+
+	switch outcome {
+	case outcomeKilled:
+		log.Info(fmt.Sprintf("PASS %s", msg))
+	case outcomeSurvived:
+		log.Info(fmt.Sprintf("FAIL %s", msg))
+
+		liveMutants = append(liveMutants, mutantInfo.mutationFileAbsPath)
+	case outcomeTimedOut:
+		log.Info(fmt.Sprintf("TIMEOUT %s", msg))
+	case outcomeCrashed:
+		log.Info(fmt.Sprintf("CRASH %s", msg))
+	case outcomeNotCompiling:
+		log.Info(fmt.Sprintf("SKIP %s", msg))
+	case outcomeEquivalent:
+		log.Info(fmt.Sprintf("EQUIVALENT %s", msg))
+	case outcomeError:
+		log.Info(fmt.Sprintf("ERROR %s", msg))
 	}
 
-	return returnOk
+	stats.record(outcome)
+
+	return result
 }
 
-func runTestsForMutant(config *MutationConfig, pkg *types.Package, originalFilePath string, file string, absMutationFile string) (execExitCode int) {
+func runTestsForMutant(config *MutationConfig, mutantInfo MutantInfo) *testRun {
 	/* // TODO might be worthwhile to check validity before running tests, because test execution can take a long time
 	_, _, _, _, err := mutesting.ParseAndTypeCheckFile(absMutationFile)
 	if err != nil {
@@ -246,78 +337,132 @@ func runTestsForMutant(config *MutationConfig, pkg *types.Package, originalFileP
 	}*/
 
 	// TODO probably want to put the whole thing in a docker container because you're gonna mess up your commands
-	runBuildCommand(config.Test.Commands.Build)
+	// every command runs inside the mutant copy so the user's checkout is never touched
+	workspace, err := newMutantWorkspace(config, mutantInfo)
+	if err != nil {
+		log.WithField("mutant", mutantInfo.mutantDirPathAbsPath).Error(err)
+		return &testRun{outcome: outcomeError}
+	}
+
+	originalFilePath := concatAddingSlashIfNeeded(config.ProjectRoot, mutantInfo.originalFileRelativePath)
+
 	defer func() {
-		runCleanUpCommand(config)
+		runCleanUpCommand(config, workspace)
 	}()
 
+	if outcome, ok := runBuildCommand(config.Test.Commands.Build, workspace); !ok {
+		return &testRun{outcome: outcome}
+	}
+
+	if config.Test.usesCluster() {
+		return runTestsForCompositions(config, mutantInfo, originalFilePath, workspace)
+	}
+
+	return runTestCommand(config, mutantInfo, originalFilePath, workspace)
+}
+
+func runTestCommand(config *MutationConfig, mutantInfo MutantInfo, originalFilePath string, workspace *mutantWorkspace) *testRun {
 	if config.Test.Commands.Test != "" {
-		return customTestMutateExec(originalFilePath, absMutationFile, config.Test.Commands.Test)
+		return customTestMutateExec(originalFilePath, mutantInfo.mutationFileAbsPath, config.Test.Commands.Test, workspace)
 	}
 
-	return defaultMutateExec(config, pkg, file, absMutationFile)
+	return defaultMutateExec(config, mutantInfo, originalFilePath, workspace)
 }
 
-func runBuildCommand(buildCommand string) {
+func runBuildCommand(buildCommand string, workspace *mutantWorkspace) (mutantOutcome, bool) {
 	log.WithField("command", buildCommand).Info("Running build command.")
 
-	if buildCommand != "" {
-		output, err := exec.Command(buildCommand).CombinedOutput()
+	if buildCommand == "" {
+		return outcomeKilled, true
+	}
 
-		log.Debug(output) // TODO out-of-order with mutation 
+	result, err := workspace.run(workspace.command(buildCommand))
+	if err != nil {
+		log.WithField("command", buildCommand).Error(err)
+		return outcomeError, false
+	}
 
-		if err != nil {
-			panic(err)
-		}
+	log.Debug(string(result.output)) // TODO out-of-order with mutation
+
+	if result.timedOut {
+		return outcomeTimedOut, false
+	} else if result.exitCode != 0 || result.signaled {
+		log.WithField("exit_code", result.exitCode).Info("Build command failed.")
+		return outcomeNotCompiling, false
 	}
+
+	return outcomeKilled, true
 }
 
-func customTestMutateExec(originalFilePath string, mutationFile string, testCommand string) (execExitCode int) {
+func customTestMutateExec(originalFilePath string, mutationFile string, testCommand string, workspace *mutantWorkspace) *testRun {
 	log.WithField("command", testCommand).Debug("Executing tests with custom test command.")
 
-	/*err := os.Chdir(dirPath)
-	if err != nil {
-		log.Error(err)
-		return returnError
-	}*/
-
-	execWithArgs := strings.Split(testCommand, " ")
-	execCommand := exec.Command(execWithArgs[0], execWithArgs[1:]...)
+	execCommand := customTestCommand(testCommand, workspace)
 
-	return executeTestCommand(originalFilePath, mutationFile, execCommand)
+	return executeTestCommand(originalFilePath, mutationFile, execCommand, workspace)
 }
 
-func defaultMutateExec(config *MutationConfig, pkg *types.Package, file string, mutationFile string) (execExitCode int) {
+func defaultMutateExec(config *MutationConfig, mutantInfo MutantInfo, originalFilePath string, workspace *mutantWorkspace) *testRun {
 	log.Debug("Execute default test command.")
 
-//	os.Chdir(file)
+	// test the package of the mutated file inside the mutant rather than the original package,
+	// or inside the project with the overlay of an overlay mutant
+	testCommand := defaultTestCommand(workspace, mutantInfo.originalFileRelativePath)
+	return executeTestCommand(originalFilePath, mutantInfo.mutationFileAbsPath, testCommand, workspace)
+}
+
+// go test for the packages of the given files, relative to the workspace
+func defaultTestCommand(workspace *mutantWorkspace, relativeFiles ...string) *exec.Cmd {
+	// -count=1 since cached results would say nothing about the mutant or the baseline duration
+	// -json gives the result of every test, see parseTestOutput
+	args := []string{"test", "-count=1", "-json", "-timeout", workspace.timeout.String()}
+	if workspace.overlay != "" {
+		args = append(args, "-overlay", workspace.overlay)
+	}
 
-	pkgName := pkg.Path()
+	seen := make(map[string]struct{})
+	for _, file := range relativeFiles {
+		pkgName := "." + string(os.PathSeparator) + filepath.Dir(file)
+		if _, ok := seen[pkgName]; !ok {
+			seen[pkgName] = struct{}{}
+			args = append(args, pkgName)
+		}
+	}
 
-	testCommand := exec.Command("go", "test", "-timeout", fmt.Sprintf("%ds", config.Test.Timeout), pkgName)
-	return executeTestCommand(file, mutationFile, testCommand)
+	return workspace.command("go", args...)
 }
 
-func executeTestCommand(originalFilePath string, mutationFile string, testCommand *exec.Cmd) int {
-	diff, execExitCode := showDiff(originalFilePath, mutationFile)
+// The custom test command, split into its arguments
+func customTestCommand(testCommand string, workspace *mutantWorkspace) *exec.Cmd {
+	execWithArgs := strings.Split(testCommand, " ")
+	return workspace.command(execWithArgs[0], execWithArgs[1:]...)
+}
 
-	test, err := testCommand.CombinedOutput()
+func executeTestCommand(originalFilePath string, mutationFile string, testCommand *exec.Cmd, workspace *mutantWorkspace) *testRun {
+	diff, _ := showDiff(originalFilePath, mutationFile)
 
-	if err == nil {
-		execExitCode = execPassed
-	} else if e, ok := err.(*exec.ExitError); ok {
-		execExitCode = e.Sys().(syscall.WaitStatus).ExitStatus()
-	} else {
-		panic(err)
+	result, err := workspace.run(testCommand)
+	if err != nil {
+		log.WithField("command", testCommand.Args).Error(err)
+		return &testRun{outcome: outcomeError}
 	}
 
-	log.Debug("Test output: ", string(test))
+	tests, allFailedTests := readTestResults(result)
 
-	putFailedTestsInMap(mutationFile, test)
+	log.Debug("Test output: ", string(result.output))
 
-	execExitCode = determinePassOrFail(diff, mutationFile, execExitCode)
+	outcome := classifyTestRun(result)
+	failedTests, onlyFlaky := excludeFlakyTests(allFailedTests)
+	if outcome == outcomeKilled && onlyFlaky {
+		// a flaky test failing says nothing about the mutant
+		log.WithField("mutant", mutationFile).Debug("Only flaky tests failed, the mutant survived.")
+		outcome = outcomeSurvived
+	}
+
+	logDiff(diff, result, outcome)
+	logFailedTests(tests)
 
-	return execExitCode
+	return &testRun{outcome, failedTests, tests, result.duration, nil}
 }
 
 func showDiff(file string, mutationFile string) (diff []byte, execExitCode int) {
@@ -338,35 +483,43 @@ func showDiff(file string, mutationFile string) (diff []byte, execExitCode int)
 	return
 }
 
-func determinePassOrFail(diff []byte, mutationFile string, execExitCode int) (int) {
-	switch execExitCode {
-	case 0: // Tests passed -> FAIL
+// Survivors and mutants that could not be judged are shown, the others only when debugging
+func logDiff(diff []byte, result *commandResult, outcome mutantOutcome) {
+	switch outcome {
+	case outcomeSurvived: // Tests passed -> FAIL
 		log.Info(string(diff))
-
-
-		return execFailed
-		liveMutants = append(liveMutants, mutationFile)
-	case 1: // Tests failed -> PASS
+	case outcomeKilled: // Tests failed -> PASS
 		log.Debug(string(diff))
-
-		return execPassed
-	case 2: // Did not compile -> SKIP
+	case outcomeTimedOut:
+		log.WithField("duration", result.duration).Debug("Mutation timed out")
+		log.Debug(string(diff))
+	case outcomeCrashed:
+		log.WithFields(log.Fields{"exit_code": result.exitCode, "signaled": result.signaled}).
+			Debug("Mutation crashed the tests")
+		log.Debug(string(diff))
+	case outcomeNotCompiling: // Did not compile -> SKIP
 		log.Debug("Mutation did not compile")
 		log.Info(string(diff))
-
-		return execSkipped
-	default: // Unknown exit code -> SKIP
-		log.WithField("exit_code", execExitCode).Info("Unknown exit code")
+	case outcomeEquivalent:
 		log.Debug(string(diff))
 	}
-	return execExitCode
 }
 
+func logFailedTests(tests []*testResult) {
+	for _, test := range tests {
+		if test.action == testFailed {
+			log.WithFields(log.Fields{"test": test.name, "package": test.pkg, "elapsed": test.elapsed}).
+				Debug("Test failed: ", test.output)
+		}
+	}
+}
+
+// Finds failed tests in plain go test output, for test commands that don't write -json
 func getFailedTests(output []byte) []string {
 	testOutput := string(output[:])
 
-	// use capturing group to get the name of the Test
-	testNameRegex := regexp.MustCompile(`FAIL:?[\s]*([\w]*)`)
+	// use capturing group to get the name of the Test, the FAIL line of a package names no test
+	testNameRegex := regexp.MustCompile(`--- FAIL:[\s]*([\w]+)`)
 	matches := testNameRegex.FindAllStringSubmatch(testOutput, -1)
 
 	failedTests := make([]string, 0)
@@ -380,8 +533,7 @@ func getFailedTests(output []byte) []string {
 
 var testsToMutants = make(map[string][]string)
 
-func putFailedTestsInMap(mutationFile string, testOutput []byte) {
-	failedTests := getFailedTests(testOutput)
+func putFailedTestsInMap(mutationFile string, failedTests []string) {
 	// if they don't fail, don't add
 
 	if len(failedTests) == 0 {
@@ -389,22 +541,11 @@ func putFailedTestsInMap(mutationFile string, testOutput []byte) {
 	}
 	// does it have to be deduplicated? I feel like no
 	testsKey := getTestKey(failedTests)
-	existingMutants, exists := testsToMutants[testsKey]
-	var newValue []string
-	if exists {
-		newValue = append(existingMutants, mutationFile)
-	}
 
-	testsToMutants[testsKey] = newValue
-}
+	resultsLock.Lock()
+	defer resultsLock.Unlock()
 
-func getRedundantCandidates() {
-	for _, mutants := range testsToMutants {
-		if len(mutants) > 1 {
-			log.WithField("mutants", mutants).Info("Potential duplicates")
-			log.Debug(testsToMutants)
-		}
-	}
+	testsToMutants[testsKey] = append(testsToMutants[testsKey], mutationFile)
 }
 
 func getTestKey(tests []string) string {
@@ -412,16 +553,21 @@ func getTestKey(tests []string) string {
 	return strings.Join(tests, ", ")
 }
 
-func runCleanUpCommand(config *MutationConfig) {
+func runCleanUpCommand(config *MutationConfig, workspace *mutantWorkspace) {
 	log.WithField("command", config.Test.Commands.CleanUp).Info("Running clean up command.")
 
 	if config.Test.Commands.CleanUp != "" {
-		output, err := exec.Command(config.Test.Commands.CleanUp).CombinedOutput()
+		result, err := workspace.run(workspace.command(config.Test.Commands.CleanUp))
+		if err != nil {
+			log.WithField("command", config.Test.Commands.CleanUp).Error(err)
+			return
+		}
 
-		log.Debug(output)
+		log.Debug(string(result.output))
 
-		if err != nil {
-			panic(err)
+		if result.timedOut || result.exitCode != 0 || result.signaled {
+			log.WithFields(log.Fields{"exit_code": result.exitCode, "timed_out": result.timedOut}).
+				Error("Clean up command failed.")
 		}
 	}
 }
\ No newline at end of file
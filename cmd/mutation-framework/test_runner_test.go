@@ -1,8 +1,13 @@
 package main
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"github.com/stretchr/testify/assert"
+	"sync"
+	"time"
 )
 
 func TestIsMutant(t *testing.T) {
@@ -34,3 +39,66 @@ func TestAppendFolder(t *testing.T) {
 func TestCreateMutantInfo(t *testing.T) {
 
 }
+
+func TestRunWorkerPool(t *testing.T) {
+	var mutants []MutantInfo
+	for _, name := range []string{"a.go.branch-if.0", "a.go.branch-if.1", "b.go.branch-if.0",
+		"b.go.branch-else.0", "c.go.statement-remove.0", "c.go.statement-remove.1"} {
+		mutants = append(mutants, MutantInfo{mutantDirPathAbsPath: name})
+	}
+
+	var lock sync.Mutex
+	executed := make(map[string]int)
+	running, mostRunning := 0, 0
+
+	runWorkerPool(3, mutants, func(mutant MutantInfo) {
+		lock.Lock()
+		executed[mutant.mutantDirPathAbsPath]++
+		running++
+		if running > mostRunning {
+			mostRunning = running
+		}
+		lock.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		lock.Lock()
+		running--
+		lock.Unlock()
+	})
+
+	assert.Len(t, executed, len(mutants))
+	for _, mutant := range mutants {
+		assert.Equal(t, 1, executed[mutant.mutantDirPathAbsPath])
+	}
+	assert.True(t, mostRunning > 1)
+	assert.True(t, mostRunning <= 3)
+}
+
+func TestCommandsThatCannotStartAreErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mutation-testing")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	original, mutant := filepath.Join(dir, "original.go"), filepath.Join(dir, "mutant.go")
+	assert.Nil(t, ioutil.WriteFile(original, []byte("package calc\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(mutant, []byte("package calc\n"), 0644))
+
+	workspace := &mutantWorkspace{dir: dir, timeout: time.Second}
+
+	outcome, ok := runBuildCommand("this-command-does-not-exist", workspace)
+	assert.False(t, ok)
+	assert.Equal(t, outcomeError, outcome)
+
+	// a build that runs and fails still means the mutant does not compile
+	outcome, ok = runBuildCommand("false", workspace)
+	assert.False(t, ok)
+	assert.Equal(t, outcomeNotCompiling, outcome)
+
+	run := executeTestCommand(original, mutant, workspace.command("this-command-does-not-exist"), workspace)
+	assert.Equal(t, outcomeError, run.outcome)
+
+	missing := &mutantWorkspace{dir: filepath.Join(dir, "this-workspace-does-not-exist"), timeout: time.Second}
+	outcome, ok = buildNodeBinary(&MutationConfig{}, missing, "node")
+	assert.False(t, ok)
+	assert.Equal(t, outcomeError, outcome)
+}
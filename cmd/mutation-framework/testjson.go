@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+// One event of the stream written by go test -json, see go doc cmd/test2json
+type testEvent struct {
+	Time    time.Time
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+const (
+	testPassed  = "pass"
+	testFailed  = "fail"
+	testSkipped = "skip"
+)
+
+// The result of one test or subtest, e.g. TestElection/three_nodes
+type testResult struct {
+	pkg     string
+	name    string
+	action  string
+	elapsed time.Duration
+	// only kept for failing tests
+	output string
+}
+
+// Names the test along with its package, since tests of different packages may have the same name
+func (result *testResult) id() string {
+	if result.pkg == "" {
+		return result.name
+	}
+
+	return result.pkg + "." + result.name
+}
+
+// Decodes the output of go test -json into the result of every test and the
+// plain text output the tests would have printed without -json.
+// Lines that aren't events, such as build errors, are kept as they are.
+// If there are no events at all, the results are nil and the output is returned unchanged.
+// Events without a test, e.g. of a package that does not build, give no results but not nil.
+func parseTestOutput(output []byte) ([]*testResult, []byte) {
+	var plain bytes.Buffer
+	var results []*testResult
+	foundEvents := false
+
+	type testKey struct{ pkg, name string }
+	testOutput := make(map[testKey]*bytes.Buffer)
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		var event testEvent
+		if len(line) == 0 || line[0] != '{' || json.Unmarshal(line, &event) != nil || event.Action == "" {
+			plain.Write(line)
+			plain.WriteByte('\n')
+			continue
+		}
+		foundEvents = true
+
+		plain.WriteString(event.Output)
+		if event.Test == "" {
+			continue
+		}
+
+		key := testKey{event.Package, event.Test}
+		switch event.Action {
+		case "output":
+			if testOutput[key] == nil {
+				testOutput[key] = new(bytes.Buffer)
+			}
+			testOutput[key].WriteString(event.Output)
+		case testPassed, testFailed, testSkipped:
+			result := &testResult{
+				pkg:     event.Package,
+				name:    event.Test,
+				action:  event.Action,
+				elapsed: time.Duration(event.Elapsed * float64(time.Second)),
+			}
+			if event.Action == testFailed && testOutput[key] != nil {
+				result.output = testOutput[key].String()
+			}
+			delete(testOutput, key)
+
+			results = append(results, result)
+		}
+	}
+
+	if !foundEvents {
+		return nil, output
+	}
+
+	// tests that were still running when the binary died (e.g. a panic) never get an
+	// action of their own, and neither do tests killed by a timeout
+	for key, buffer := range testOutput {
+		results = append(results, &testResult{pkg: key.pkg, name: key.name, action: testFailed, output: buffer.String()})
+	}
+
+	if results == nil {
+		results = []*testResult{}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].pkg != results[j].pkg {
+			return results[i].pkg < results[j].pkg
+		}
+		return results[i].name < results[j].name
+	})
+
+	return results, plain.Bytes()
+}
+
+// Ids of the failing tests, see testResult.id. A test whose subtests failed is left out,
+// since the subtests say more precisely what killed the mutant.
+func failedTestNames(results []*testResult) []string {
+	failed := make(map[string]struct{})
+	for _, result := range results {
+		if result.action == testFailed {
+			failed[result.id()] = struct{}{}
+		}
+	}
+
+	names := make([]string, 0, len(failed))
+	for name := range failed {
+		if !hasFailedSubtest(name, failed) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func hasFailedSubtest(name string, failed map[string]struct{}) bool {
+	for other := range failed {
+		if strings.HasPrefix(other, name+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reads the per-test results from the output of a test command. Afterwards the output
+// of the command result is plain text, whether it was written with -json or not.
+// The failed tests come from the regex only for commands that don't write events at all.
+func readTestResults(result *commandResult) (tests []*testResult, failedTests []string) {
+	tests, result.output = parseTestOutput(result.output)
+
+	if tests == nil {
+		return nil, getFailedTests(result.output)
+	}
+
+	return tests, failedTestNames(tests)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleTestJson = `{"Action":"start","Package":"example/raft"}
+{"Action":"run","Package":"example/raft","Test":"TestElection"}
+{"Action":"output","Package":"example/raft","Test":"TestElection","Output":"=== RUN   TestElection\n"}
+{"Action":"run","Package":"example/raft","Test":"TestElection/three_nodes"}
+{"Action":"output","Package":"example/raft","Test":"TestElection/three_nodes","Output":"    raft_test.go:12: no leader\n"}
+{"Action":"fail","Package":"example/raft","Test":"TestElection/three_nodes","Elapsed":0.5}
+{"Action":"run","Package":"example/raft","Test":"TestElection/one_node"}
+{"Action":"pass","Package":"example/raft","Test":"TestElection/one_node","Elapsed":0.1}
+{"Action":"fail","Package":"example/raft","Test":"TestElection","Elapsed":0.6}
+{"Action":"run","Package":"example/raft","Test":"TestSnapshot"}
+{"Action":"skip","Package":"example/raft","Test":"TestSnapshot","Elapsed":0}
+{"Action":"run","Package":"example/raft","Test":"TestAppend"}
+{"Action":"output","Package":"example/raft","Test":"TestAppend","Output":"panic: runtime error: index out of range\n"}
+{"Action":"output","Package":"example/raft","Output":"FAIL\texample/raft\t0.700s\n"}
+{"Action":"fail","Package":"example/raft","Elapsed":0.7}
+`
+
+func TestParseTestOutput(t *testing.T) {
+	tests, plain := parseTestOutput([]byte(sampleTestJson))
+
+	actions := make(map[string]string)
+	for _, test := range tests {
+		actions[test.name] = test.action
+	}
+
+	assert.Equal(t, map[string]string{
+		"TestElection":             testFailed,
+		"TestElection/three_nodes": testFailed,
+		"TestElection/one_node":    testPassed,
+		"TestSnapshot":             testSkipped,
+		"TestAppend":               testFailed,
+	}, actions)
+
+	for _, test := range tests {
+		switch test.name {
+		case "TestElection/three_nodes":
+			assert.Equal(t, "example/raft", test.pkg)
+			assert.Equal(t, 500*time.Millisecond, test.elapsed)
+			assert.Contains(t, test.output, "no leader")
+		case "TestElection/one_node":
+			assert.Empty(t, test.output)
+		}
+	}
+
+	assert.Contains(t, string(plain), "panic: runtime error")
+	assert.Contains(t, string(plain), "FAIL\texample/raft")
+	assert.NotContains(t, string(plain), `"Action"`)
+
+	assert.Equal(t, []string{"example/raft.TestAppend", "example/raft.TestElection/three_nodes"}, failedTestNames(tests))
+}
+
+func TestParseTestOutputKeepsOtherLines(t *testing.T) {
+	buildError := "# example/raft\nraft.go:3:2: undefined: leader\n"
+	output := buildError + `{"Action":"output","Package":"example/raft","Output":"FAIL\texample/raft [build failed]\n"}` + "\n"
+
+	tests, plain := parseTestOutput([]byte(output))
+	assert.NotNil(t, tests)
+	assert.Empty(t, tests)
+	assert.Equal(t, buildError+"FAIL\texample/raft [build failed]\n", string(plain))
+
+	notJson := []byte("--- FAIL: TestElection (0.00s)\nFAIL\n")
+	tests, plain = parseTestOutput(notJson)
+	assert.Nil(t, tests)
+	assert.Equal(t, notJson, plain)
+}
+
+func TestReadTestResults(t *testing.T) {
+	result := &commandResult{exitCode: 1, output: []byte(sampleTestJson)}
+
+	tests, failed := readTestResults(result)
+	assert.Len(t, tests, 5)
+	assert.Equal(t, []string{"example/raft.TestAppend", "example/raft.TestElection/three_nodes"}, failed)
+	assert.Equal(t, outcomeCrashed, classifyTestRun(result))
+
+	result = &commandResult{exitCode: 1, output: []byte("--- FAIL: TestElection (0.00s)\n")}
+	tests, failed = readTestResults(result)
+	assert.Nil(t, tests)
+	assert.Equal(t, []string{"TestElection"}, failed)
+
+	// a package that does not build names no test, whether it was written with -json or not
+	buildFailed := "# example/raft\nraft.go:3:2: undefined: leader\n"
+	result = &commandResult{exitCode: 1, output: []byte(buildFailed +
+		`{"Action":"output","Package":"example/raft","Output":"FAIL\texample/raft [build failed]\n"}` + "\n" +
+		`{"Action":"fail","Package":"example/raft","Elapsed":0}` + "\n")}
+	tests, failed = readTestResults(result)
+	assert.Empty(t, tests)
+	assert.Empty(t, failed)
+
+	result = &commandResult{exitCode: 2, output: []byte(buildFailed + "FAIL\texample/raft [build failed]\n")}
+	_, failed = readTestResults(result)
+	assert.Empty(t, failed)
+}
+
+func TestFailedTestNamesOfPackages(t *testing.T) {
+	tests := []*testResult{
+		{pkg: "example/raft", name: "TestStart", action: testFailed},
+		{pkg: "example/log", name: "TestStart", action: testFailed},
+		{pkg: "example/log", name: "TestAppend", action: testFailed},
+		// a subtest only replaces its parent in its own package
+		{pkg: "example/raft", name: "TestAppend/empty", action: testFailed},
+	}
+
+	assert.Equal(t, []string{"example/log.TestAppend", "example/log.TestStart",
+		"example/raft.TestAppend/empty", "example/raft.TestStart"}, failedTestNames(tests))
+}
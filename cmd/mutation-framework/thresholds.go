@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// A score or survivor threshold that the run did not meet
+type thresholdViolation struct {
+	// "total", "file" or "package"
+	scope string
+	name  string
+	stats *mutationStats
+	// what was required, e.g. "min_score 0.80"
+	requirement string
+}
+
+func (violation thresholdViolation) String() string {
+	subject := violation.scope + " " + violation.name
+	if violation.scope == "total" {
+		subject = "all files together"
+	}
+
+	return fmt.Sprintf("%s: mutation score is %f with %d survivors, which misses %s",
+		subject, violation.stats.Score(), violation.stats.failed, violation.requirement)
+}
+
+// Whether any of min_score, min_scores or max_survivors is set
+func (test *Test) hasThresholds() bool {
+	return test.MinScore != nil || len(test.MinScores) > 0 || test.MaxSurvivors != nil
+}
+
+// Checks the results against the thresholds of the config
+// min_scores are looked up by the relative path of a file first, then by package or directory.
+// A score without tested mutants, e.g. after --since or when all are suppressed, is not checked.
+func checkThresholds(config *MutationConfig, results []*mutantResult, allStats map[string]*mutationStats) []thresholdViolation {
+	var violations []thresholdViolation
+
+	total := &mutationStats{}
+	for _, stats := range allStats {
+		total.add(stats)
+	}
+
+	if config.Test.MinScore != nil && total.Total() > 0 && total.Score() < *config.Test.MinScore {
+		violations = append(violations, thresholdViolation{"total", "", total,
+			fmt.Sprintf("min_score %.2f", *config.Test.MinScore)})
+	}
+
+	if config.Test.MaxSurvivors != nil && total.failed > *config.Test.MaxSurvivors {
+		violations = append(violations, thresholdViolation{"total", "", total,
+			fmt.Sprintf("max_survivors %d", *config.Test.MaxSurvivors)})
+	}
+
+	var names []string
+	for name := range config.Test.MinScores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		minimum := config.Test.MinScores[name]
+		scope, stats := "file", allStats[name]
+		if stats == nil {
+			scope, stats = "package", getPackageStats(name, results)
+		}
+
+		if stats == nil {
+			log.WithField("name", name).Warn("No mutants for min_scores entry, is the file or package right?")
+			continue
+		}
+
+		if stats.Total() == 0 {
+			log.WithField("name", name).Info("No tested mutants for min_scores entry, skipping it.")
+			continue
+		}
+
+		if stats.Score() < minimum {
+			violations = append(violations, thresholdViolation{scope, name, stats,
+				fmt.Sprintf("min_score %.2f", minimum)})
+		}
+	}
+
+	return violations
+}
+
+// Counts the outcomes of the mutants of a package, which is given by its
+// import path or its directory relative to the project root. Nil if it has no mutants.
+func getPackageStats(pkg string, results []*mutantResult) *mutationStats {
+	var stats *mutationStats
+	for _, result := range results {
+		dir := filepath.ToSlash(filepath.Dir(result.mutant.originalFileRelativePath))
+		if getMutantPackage(result.mutant) != pkg && dir != filepath.ToSlash(filepath.Clean(pkg)) {
+			continue
+		}
+
+		if stats == nil {
+			stats = &mutationStats{}
+		}
+		stats.record(result.outcome)
+	}
+
+	return stats
+}
+
+// Files with surviving mutants, the lowest score first
+func getFilesWithSurvivors(allStats map[string]*mutationStats) []string {
+	var files []string
+	for file, stats := range allStats {
+		if stats.failed > 0 {
+			files = append(files, file)
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if allStats[files[i]].Score() != allStats[files[j]].Score() {
+			return allStats[files[i]].Score() < allStats[files[j]].Score()
+		}
+		return files[i] < files[j]
+	})
+
+	return files
+}
+
+func (ms *mutationStats) add(other *mutationStats) {
+	ms.passed += other.passed
+	ms.failed += other.failed
+	ms.duplicated += other.duplicated
+	ms.skipped += other.skipped
+	ms.timedOut += other.timedOut
+	ms.crashed += other.crashed
+	ms.suppressed += other.suppressed
+	ms.equivalent += other.equivalent
+	ms.errors += other.errors
+}
+
+// Logs every threshold that was not met and returns the exit code for the run
+func enforceThresholds(config *MutationConfig, results []*mutantResult, allStats map[string]*mutationStats) int {
+	if !config.Test.hasThresholds() {
+		return returnOk
+	}
+
+	violations := checkThresholds(config, results, allStats)
+	failedTotal := false
+	for _, violation := range violations {
+		log.WithFields(log.Fields{"scope": violation.scope, "name": violation.name}).Error(violation.String())
+		failedTotal = failedTotal || violation.scope == "total"
+	}
+
+	if failedTotal {
+		// a threshold for all files says nothing about where to look, so point at the survivors
+		for _, file := range getFilesWithSurvivors(allStats) {
+			log.WithFields(log.Fields{"file": file, "score": allStats[file].Score(), "survivors": allStats[file].failed}).
+				Error("File has surviving mutants.")
+		}
+	}
+
+	if len(violations) > 0 {
+		return returnThresholdFailed
+	}
+
+	log.Info("All mutation score thresholds are met.")
+	return returnOk
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func thresholdResult(file string, outcome mutantOutcome) *mutantResult {
+	return newMutantResult(MutantInfo{originalFileRelativePath: file}, &testRun{outcome: outcome})
+}
+
+func getThresholdResults() ([]*mutantResult, map[string]*mutationStats) {
+	results := []*mutantResult{
+		thresholdResult("raft/raft.go", outcomeKilled),
+		thresholdResult("raft/raft.go", outcomeSurvived),
+		thresholdResult("raft/log.go", outcomeKilled),
+		thresholdResult("server.go", outcomeKilled),
+		thresholdResult("server.go", outcomeTimedOut),
+	}
+	allStats := map[string]*mutationStats{
+		"raft/raft.go": {passed: 1, failed: 1},
+		"raft/log.go":  {passed: 1},
+		"server.go":    {passed: 1, timedOut: 1},
+	}
+
+	return results, allStats
+}
+
+func TestCheckThresholds(t *testing.T) {
+	results, allStats := getThresholdResults()
+
+	minScore, maxSurvivors := 0.9, 0
+	config := &MutationConfig{Test: Test{
+		MinScore:     &minScore,
+		MaxSurvivors: &maxSurvivors,
+		MinScores:    map[string]float64{"raft/raft.go": 0.5, "raft": 0.7, "server.go": 1.0, "missing": 1.0},
+	}}
+
+	violations := checkThresholds(config, results, allStats)
+	assert.Len(t, violations, 3)
+
+	assert.Equal(t, "total", violations[0].scope)
+	assert.Equal(t, "min_score 0.90", violations[0].requirement)
+	assert.Equal(t, 0.8, violations[0].stats.Score())
+	assert.Equal(t, "max_survivors 0", violations[1].requirement)
+
+	// raft/raft.go alone meets 0.5, the package with 2 of 3 killed doesn't meet 0.7
+	assert.Equal(t, "package", violations[2].scope)
+	assert.Equal(t, "raft", violations[2].name)
+	assert.Equal(t, "package raft: mutation score is 0.666667 with 1 survivors, which misses min_score 0.70",
+		violations[2].String())
+}
+
+func TestEnforceThresholds(t *testing.T) {
+	results, allStats := getThresholdResults()
+
+	assert.Equal(t, returnOk, enforceThresholds(&MutationConfig{}, results, allStats))
+
+	minScore, maxSurvivors := 0.8, 1
+	config := &MutationConfig{Test: Test{MinScore: &minScore, MaxSurvivors: &maxSurvivors}}
+	assert.Equal(t, returnOk, enforceThresholds(config, results, allStats))
+
+	maxSurvivors = 0
+	assert.Equal(t, returnThresholdFailed, enforceThresholds(config, results, allStats))
+}
+
+func TestCheckThresholdsWithoutTestedMutants(t *testing.T) {
+	results := []*mutantResult{
+		thresholdResult("raft/raft.go", outcomeEquivalent),
+		thresholdResult("raft/raft.go", outcomeError),
+	}
+	allStats := map[string]*mutationStats{
+		"raft/raft.go": {equivalent: 1, errors: 1, suppressed: 2},
+	}
+
+	minScore := 0.9
+	config := &MutationConfig{Test: Test{MinScore: &minScore, MinScores: map[string]float64{"raft/raft.go": 0.9, "raft": 0.9}}}
+	assert.Empty(t, checkThresholds(config, results, allStats))
+	assert.Empty(t, checkThresholds(config, nil, map[string]*mutationStats{}))
+	assert.Equal(t, returnOk, enforceThresholds(config, nil, map[string]*mutationStats{}))
+}
+
+func TestGetFilesWithSurvivors(t *testing.T) {
+	allStats := map[string]*mutationStats{
+		"a.go": {passed: 3, failed: 1},
+		"b.go": {passed: 1, failed: 1},
+		"c.go": {passed: 1},
+	}
+
+	assert.Equal(t, []string{"b.go", "a.go"}, getFilesWithSurvivors(allStats))
+}
+
+func TestThresholdConfig(t *testing.T) {
+	config, err := parseAndValidateConfig([]byte(`{"project_root":"home","test":{"min_score":0.8,"max_survivors":0,"min_scores":{"raft":0.9}}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, 0.8, *config.Test.MinScore)
+	assert.Equal(t, 0, *config.Test.MaxSurvivors)
+	assert.Equal(t, map[string]float64{"raft": 0.9}, config.Test.MinScores)
+
+	_, err = parseAndValidateConfig([]byte(`{"project_root":"home","test":{"min_score":80}}`))
+	assert.NotNil(t, err)
+
+	_, err = parseAndValidateConfig([]byte(`{"project_root":"home","test":{"min_scores":{"raft":-1}}}`))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"go/build"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Name of the GOPATH that is created inside a mutant for projects without go.mod
+// The leading dot keeps ./... patterns from descending into it
+const mutantGopathFolder = ".gopath"
+
+// The directory and environment which the commands of one mutant are run with
+// so that they never touch the user's checkout. Overlay mutants run in the checkout,
+// but the go tool only reads it.
+type mutantWorkspace struct {
+	dir     string
+	env     []string
+	timeout time.Duration
+	// passed to go test with -overlay, empty unless the mutant is an overlay mutant
+	overlay string
+}
+
+// Sets up the workspace for the given mutant copy
+func newMutantWorkspace(config *MutationConfig, mutantInfo MutantInfo) (*mutantWorkspace, error) {
+	var workspace *mutantWorkspace
+	var err error
+	if config.Mutate.Schemata {
+		workspace, err = newSchemataWorkspace(config, mutantInfo)
+	} else if config.Mutate.Overlay {
+		workspace, err = newOverlayWorkspace(config, mutantInfo)
+	} else {
+		workspace, err = newWorkspace(config, mutantInfo.mutantDirPathAbsPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	workspace.timeout = config.Test.getMutantTimeout()
+
+	// the environment documented for exec commands
+	workspace.env = setEnv(workspace.env, "MUTATE_CHANGED", mutantInfo.mutationFileAbsPath)
+	workspace.env = setEnv(workspace.env, "MUTATE_ORIGINAL",
+		concatAddingSlashIfNeeded(config.ProjectRoot, mutantInfo.originalFileRelativePath))
+	if mutantInfo.pkg != nil {
+		workspace.env = setEnv(workspace.env, "MUTATE_PACKAGE", mutantInfo.pkg.Path())
+	}
+	workspace.env = setEnv(workspace.env, "MUTATE_TIMEOUT", fmt.Sprintf("%d", int(workspace.timeout.Seconds())))
+	workspace.env = setEnv(workspace.env, "MUTATE_DEBUG", fmt.Sprintf("%t", config.Verbose))
+	workspace.env = setEnv(workspace.env, "MUTATE_VERBOSE", fmt.Sprintf("%t", config.Verbose))
+
+	return workspace, nil
+}
+
+// Sets up a workspace for a copy of the project
+// Module projects are resolved through their go.mod inside the copy,
+// GOPATH projects get a GOPATH of their own which points at the copy
+func newWorkspace(config *MutationConfig, copyDir string) (*mutantWorkspace, error) {
+	workspace := &mutantWorkspace{
+		dir:     copyDir,
+		env:     os.Environ(),
+		timeout: config.Test.getTimeout(),
+	}
+
+	if isModuleProject(copyDir) {
+		log.WithField("mutant", copyDir).Debug("Resolving packages with go.mod of mutant.")
+	} else if importPath, ok := getGopathImportPath(config.ProjectRoot, build.Default.GOPATH); ok {
+		gopath := appendFolder(copyDir, mutantGopathFolder)
+		linkedDir := appendFolder(appendFolder(gopath, "src"), importPath)
+
+		err := linkMutantIntoGopath(copyDir, linkedDir)
+		if err != nil {
+			return nil, err
+		}
+
+		log.WithFields(log.Fields{"mutant": copyDir, "gopath": gopath}).
+			Debug("Resolving packages with GOPATH of mutant.")
+
+		workspace.dir = linkedDir
+		workspace.env = setEnv(workspace.env, "GOPATH",
+			gopath+string(os.PathListSeparator)+build.Default.GOPATH)
+		workspace.env = setEnv(workspace.env, "GO111MODULE", "off")
+	} else {
+		log.WithField("project_root", config.ProjectRoot).
+			Debug("Project is neither a module nor inside GOPATH, running commands in mutant directory only.")
+	}
+
+	// go resolves relative packages with PWD, so it has to match the directory
+	workspace.env = setEnv(workspace.env, "PWD", workspace.dir)
+
+	return workspace, nil
+}
+
+// Creates a command which runs inside the workspace
+func (workspace *mutantWorkspace) command(name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = workspace.dir
+	cmd.Env = workspace.env
+
+	return cmd
+}
+
+// Runs a command of the workspace, killing it once the timeout is up
+func (workspace *mutantWorkspace) run(cmd *exec.Cmd) (*commandResult, error) {
+	return runCommand(cmd, workspace.timeout)
+}
+
+func isModuleProject(dir string) bool {
+	_, err := FS.Stat(appendFolder(dir, "go.mod"))
+	return err == nil
+}
+
+// Returns the import path of the project root if it lies inside one of the GOPATH entries
+func getGopathImportPath(projectRoot string, gopath string) (string, bool) {
+	root := filepath.Clean(projectRoot)
+
+	for _, entry := range filepath.SplitList(gopath) {
+		if entry == "" {
+			continue
+		}
+
+		src := filepath.Join(filepath.Clean(entry), "src") + string(os.PathSeparator)
+		if strings.HasPrefix(root, src) {
+			return filepath.ToSlash(strings.TrimPrefix(root, src)), true
+		}
+	}
+
+	return "", false
+}
+
+// Makes the mutant available at its import path inside its own GOPATH
+func linkMutantIntoGopath(mutantDir string, linkedDir string) error {
+	if _, err := os.Lstat(linkedDir); err == nil {
+		// already linked by an earlier run of this mutant
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(linkedDir), 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.Symlink(mutantDir, linkedDir)
+}
+
+// Replaces or adds key=value in the environment
+func setEnv(env []string, key string, value string) []string {
+	prefix := key + "="
+
+	newEnv := make([]string, 0, len(env)+1)
+	for _, variable := range env {
+		if !strings.HasPrefix(variable, prefix) {
+			newEnv = append(newEnv, variable)
+		}
+	}
+
+	return append(newEnv, prefix+value)
+}
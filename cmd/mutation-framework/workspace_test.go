@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetGopathImportPath(t *testing.T) {
+	importPath, ok := getGopathImportPath("/home/amy/go/src/github.com/amyjzhu/mutation-framework/",
+		"/home/amy/go")
+	assert.True(t, ok)
+	assert.Equal(t, "github.com/amyjzhu/mutation-framework", importPath)
+
+	importPath, ok = getGopathImportPath("/work/src/etcd", "/home/amy/go:/work/")
+	assert.True(t, ok)
+	assert.Equal(t, "etcd", importPath)
+
+	_, ok = getGopathImportPath("/home/amy/projects/etcd", "/home/amy/go")
+	assert.False(t, ok)
+
+	_, ok = getGopathImportPath("/home/amy/go/srcs/etcd", "/home/amy/go")
+	assert.False(t, ok)
+}
+
+func TestSetEnv(t *testing.T) {
+	env := []string{"GOPATH=/home/amy/go", "HOME=/home/amy", "GOPATHS=other"}
+
+	env = setEnv(env, "GOPATH", "/tmp/mutant/.gopath")
+	assert.ElementsMatch(t, []string{"HOME=/home/amy", "GOPATHS=other", "GOPATH=/tmp/mutant/.gopath"}, env)
+
+	env = setEnv(env, "MUTATE_DEBUG", "true")
+	assert.Contains(t, env, "MUTATE_DEBUG=true")
+}
@@ -0,0 +1,312 @@
+// Package faultproxy forwards TCP and UDP traffic between local addresses and injects
+// network faults into it, like delays, drops and partitions, following a schedule.
+package faultproxy
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// How long a reordered message is held back, so that the messages after it overtake it
+const reorderDelay = 50 * time.Millisecond
+
+// Link forwards what is sent to Listen on to Upstream, and the answers back
+type Link struct {
+	Name     string
+	Protocol string // tcp (default) or udp
+	Listen   string
+	Upstream string
+}
+
+// Fault is injected into the messages of the links it names while it is active.
+// On TCP every read from a connection counts as a message.
+type Fault struct {
+	Links    []string      // names of the links, every link if empty
+	Start    time.Duration // since the proxy started
+	Duration time.Duration // until the proxy stops if 0
+	Delay    time.Duration
+	Jitter   time.Duration // random extra delay up to this
+	// probabilities between 0 and 1 for each message
+	Drop      float64
+	Duplicate float64
+	Reorder   float64
+	// drops every message and refuses new TCP connections
+	Partition bool
+}
+
+// Stats counts what happened to the messages of a link
+type Stats struct {
+	Forwarded  int
+	Dropped    int
+	Duplicated int
+	Reordered  int
+}
+
+// Proxy forwards the traffic of its links until it is stopped
+type Proxy struct {
+	schedule []Fault
+	started  time.Time
+	done     chan struct{}
+	wg       sync.WaitGroup
+
+	lock   sync.Mutex
+	random *rand.Rand
+	addrs  map[string]net.Addr
+	// listeners and connections, nil once stopped
+	open  map[io.Closer]struct{}
+	stats map[string]*Stats
+}
+
+// Writes the messages of one direction, one message at a time
+type writer struct {
+	lock  sync.Mutex
+	write func([]byte) error
+}
+
+// Start listens on the addresses of the links and starts the schedule
+// The same seed gives the same faults for the same messages.
+func Start(links []Link, schedule []Fault, seed int64) (*Proxy, error) {
+	proxy := &Proxy{
+		schedule: schedule,
+		started:  time.Now(),
+		done:     make(chan struct{}),
+		random:   rand.New(rand.NewSource(seed)),
+		addrs:    make(map[string]net.Addr),
+		open:     make(map[io.Closer]struct{}),
+		stats:    make(map[string]*Stats),
+	}
+
+	for _, link := range links {
+		proxy.stats[link.Name] = &Stats{}
+	}
+	for _, fault := range schedule {
+		for _, name := range fault.Links {
+			if _, ok := proxy.stats[name]; !ok {
+				return nil, fmt.Errorf("fault names unknown link %q", name)
+			}
+		}
+	}
+
+	for _, link := range links {
+		var err error
+		switch link.Protocol {
+		case "", "tcp":
+			err = proxy.listenTCP(link)
+		case "udp":
+			err = proxy.listenUDP(link)
+		default:
+			err = fmt.Errorf("protocol must be tcp or udp, but is %q", link.Protocol)
+		}
+		if err != nil {
+			proxy.Stop()
+			return nil, fmt.Errorf("could not proxy %s: %v", link.Name, err)
+		}
+	}
+
+	return proxy, nil
+}
+
+// Stop closes the listeners and connections of the proxy, dropping messages still held back
+func (proxy *Proxy) Stop() {
+	proxy.lock.Lock()
+	if proxy.open == nil {
+		proxy.lock.Unlock()
+		return
+	}
+	close(proxy.done)
+	for closer := range proxy.open {
+		closer.Close()
+	}
+	proxy.open = nil
+	proxy.lock.Unlock()
+
+	proxy.wg.Wait()
+}
+
+// Addr returns the address the link listens on, which is useful with port 0
+func (proxy *Proxy) Addr(name string) net.Addr {
+	proxy.lock.Lock()
+	defer proxy.lock.Unlock()
+
+	return proxy.addrs[name]
+}
+
+// Stats returns what happened to the messages of every link so far, by name
+func (proxy *Proxy) Stats() map[string]Stats {
+	proxy.lock.Lock()
+	defer proxy.lock.Unlock()
+
+	stats := make(map[string]Stats, len(proxy.stats))
+	for name, linkStats := range proxy.stats {
+		stats[name] = *linkStats
+	}
+
+	return stats
+}
+
+// The faults of the schedule which are active on the link right now, combined into one
+func (proxy *Proxy) active(name string) Fault {
+	elapsed := time.Since(proxy.started)
+
+	var active Fault
+	for _, fault := range proxy.schedule {
+		if elapsed < fault.Start || (fault.Duration > 0 && elapsed >= fault.Start+fault.Duration) ||
+			!fault.appliesTo(name) {
+			continue
+		}
+
+		if fault.Delay > active.Delay {
+			active.Delay = fault.Delay
+		}
+		if fault.Jitter > active.Jitter {
+			active.Jitter = fault.Jitter
+		}
+		if fault.Drop > active.Drop {
+			active.Drop = fault.Drop
+		}
+		if fault.Duplicate > active.Duplicate {
+			active.Duplicate = fault.Duplicate
+		}
+		if fault.Reorder > active.Reorder {
+			active.Reorder = fault.Reorder
+		}
+		active.Partition = active.Partition || fault.Partition
+	}
+
+	return active
+}
+
+func (fault Fault) appliesTo(name string) bool {
+	if len(fault.Links) == 0 {
+		return true
+	}
+
+	for _, link := range fault.Links {
+		if link == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sends the message on with the faults which are active on the link
+func (proxy *Proxy) deliver(name string, message []byte, out *writer) {
+	fault := proxy.active(name)
+	if fault.Partition || proxy.chance(fault.Drop) {
+		proxy.count(name, func(stats *Stats) { stats.Dropped++ })
+		return
+	}
+
+	delay := fault.Delay
+	if fault.Jitter > 0 {
+		proxy.lock.Lock()
+		delay += time.Duration(proxy.random.Int63n(int64(fault.Jitter)))
+		proxy.lock.Unlock()
+	}
+	if !proxy.wait(delay) {
+		return
+	}
+
+	copies := 1
+	if proxy.chance(fault.Duplicate) {
+		copies = 2
+		proxy.count(name, func(stats *Stats) { stats.Duplicated++ })
+	}
+	proxy.count(name, func(stats *Stats) { stats.Forwarded++ })
+
+	if proxy.chance(fault.Reorder) {
+		proxy.count(name, func(stats *Stats) { stats.Reordered++ })
+
+		// the buffer of the message is read into again
+		held := append([]byte(nil), message...)
+		proxy.wg.Add(1)
+		go func() {
+			defer proxy.wg.Done()
+			if proxy.wait(reorderDelay) {
+				out.send(held, copies)
+			}
+		}()
+		return
+	}
+
+	out.send(message, copies)
+}
+
+func (out *writer) send(message []byte, copies int) {
+	out.lock.Lock()
+	defer out.lock.Unlock()
+
+	for i := 0; i < copies; i++ {
+		if out.write(message) != nil {
+			return
+		}
+	}
+}
+
+func (proxy *Proxy) chance(probability float64) bool {
+	if probability <= 0 {
+		return false
+	}
+
+	proxy.lock.Lock()
+	defer proxy.lock.Unlock()
+
+	return proxy.random.Float64() < probability
+}
+
+func (proxy *Proxy) count(name string, update func(stats *Stats)) {
+	proxy.lock.Lock()
+	defer proxy.lock.Unlock()
+
+	update(proxy.stats[name])
+}
+
+// Waits for the duration, false if the proxy was stopped in the meantime
+func (proxy *Proxy) wait(duration time.Duration) bool {
+	if duration <= 0 {
+		select {
+		case <-proxy.done:
+			return false
+		default:
+			return true
+		}
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-proxy.done:
+		return false
+	}
+}
+
+// Remembers the listener or connection so that Stop closes it
+// If the proxy is stopped already, it is closed right away and false is returned.
+func (proxy *Proxy) track(closer io.Closer) bool {
+	proxy.lock.Lock()
+	defer proxy.lock.Unlock()
+
+	if proxy.open == nil {
+		closer.Close()
+		return false
+	}
+
+	proxy.open[closer] = struct{}{}
+	return true
+}
+
+func (proxy *Proxy) untrack(closer io.Closer) {
+	proxy.lock.Lock()
+	defer proxy.lock.Unlock()
+
+	delete(proxy.open, closer)
+	closer.Close()
+}
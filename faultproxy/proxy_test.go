@@ -0,0 +1,167 @@
+package faultproxy
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func startTCPEcho(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	return listener
+}
+
+func startUDPEcho(t *testing.T) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	go func() {
+		buffer := make([]byte, 1024)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			conn.WriteTo(buffer[:n], addr)
+		}
+	}()
+
+	return conn
+}
+
+// Sends a line through the proxy and reads the answer, empty if there is none in time
+func echoTCP(t *testing.T, proxy *Proxy, name string) string {
+	conn, err := net.Dial("tcp", proxy.Addr(name).String())
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(500 * time.Millisecond))
+	conn.Write([]byte("ping\n"))
+	line, _ := bufio.NewReader(conn).ReadString('\n')
+
+	return line
+}
+
+// Sends a datagram through the proxy and collects the answers that arrive in time
+func echoUDP(t *testing.T, proxy *Proxy, name string) []string {
+	conn, err := net.Dial("udp", proxy.Addr(name).String())
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	conn.Write([]byte("ping"))
+
+	var answers []string
+	buffer := make([]byte, 1024)
+	for {
+		conn.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return answers
+		}
+		answers = append(answers, string(buffer[:n]))
+	}
+}
+
+func TestProxyTCP(t *testing.T) {
+	echo := startTCPEcho(t)
+	defer echo.Close()
+
+	proxy, err := Start([]Link{
+		{Name: "plain", Listen: "127.0.0.1:0", Upstream: echo.Addr().String()},
+		{Name: "slow", Listen: "127.0.0.1:0", Upstream: echo.Addr().String()},
+		{Name: "cut", Listen: "127.0.0.1:0", Upstream: echo.Addr().String()},
+	}, []Fault{
+		{Links: []string{"slow"}, Delay: 100 * time.Millisecond},
+		{Links: []string{"cut"}, Partition: true},
+	}, 1)
+	assert.Nil(t, err)
+	defer proxy.Stop()
+
+	assert.Equal(t, "ping\n", echoTCP(t, proxy, "plain"))
+
+	start := time.Now()
+	assert.Equal(t, "ping\n", echoTCP(t, proxy, "slow"))
+	// both directions are delayed
+	assert.True(t, time.Since(start) >= 200*time.Millisecond)
+
+	assert.Equal(t, "", echoTCP(t, proxy, "cut"))
+	assert.Equal(t, 1, proxy.Stats()["cut"].Dropped)
+	assert.Equal(t, 2, proxy.Stats()["plain"].Forwarded)
+}
+
+func TestProxyUDP(t *testing.T) {
+	echo := startUDPEcho(t)
+	defer echo.Close()
+
+	proxy, err := Start([]Link{
+		{Name: "plain", Protocol: "udp", Listen: "127.0.0.1:0", Upstream: echo.LocalAddr().String()},
+		{Name: "lossy", Protocol: "udp", Listen: "127.0.0.1:0", Upstream: echo.LocalAddr().String()},
+		{Name: "echoing", Protocol: "udp", Listen: "127.0.0.1:0", Upstream: echo.LocalAddr().String()},
+	}, []Fault{
+		{Links: []string{"lossy"}, Drop: 1},
+		{Links: []string{"echoing"}, Duplicate: 1},
+	}, 1)
+	assert.Nil(t, err)
+	defer proxy.Stop()
+
+	assert.Equal(t, []string{"ping"}, echoUDP(t, proxy, "plain"))
+	assert.Empty(t, echoUDP(t, proxy, "lossy"))
+	// duplicated on the way there and on the way back
+	assert.Equal(t, []string{"ping", "ping", "ping", "ping"}, echoUDP(t, proxy, "echoing"))
+}
+
+func TestProxySchedule(t *testing.T) {
+	echo := startTCPEcho(t)
+	defer echo.Close()
+
+	proxy, err := Start([]Link{{Name: "node", Listen: "127.0.0.1:0", Upstream: echo.Addr().String()}},
+		[]Fault{{Start: 200 * time.Millisecond, Duration: 400 * time.Millisecond, Partition: true}}, 1)
+	assert.Nil(t, err)
+	defer proxy.Stop()
+
+	assert.Equal(t, "ping\n", echoTCP(t, proxy, "node"))
+	time.Sleep(250 * time.Millisecond)
+	assert.Equal(t, "", echoTCP(t, proxy, "node"))
+	time.Sleep(400 * time.Millisecond)
+	assert.Equal(t, "ping\n", echoTCP(t, proxy, "node"))
+}
+
+func TestActiveFaults(t *testing.T) {
+	proxy := &Proxy{started: time.Now(), schedule: []Fault{
+		{Delay: time.Second, Drop: 0.1},
+		{Links: []string{"a"}, Delay: 2 * time.Second, Reorder: 0.5},
+		{Links: []string{"b"}, Partition: true},
+		{Start: time.Hour, Partition: true},
+	}}
+
+	assert.Equal(t, Fault{Delay: 2 * time.Second, Drop: 0.1, Reorder: 0.5}, proxy.active("a"))
+	assert.Equal(t, Fault{Delay: time.Second, Drop: 0.1, Partition: true}, proxy.active("b"))
+}
+
+func TestStartErrors(t *testing.T) {
+	_, err := Start([]Link{{Name: "a", Listen: "127.0.0.1:0", Upstream: "127.0.0.1:1"}},
+		[]Fault{{Links: []string{"b"}, Partition: true}}, 1)
+	assert.Error(t, err)
+
+	_, err = Start([]Link{{Name: "a", Protocol: "sctp", Listen: "127.0.0.1:0", Upstream: "127.0.0.1:1"}}, nil, 1)
+	assert.Error(t, err)
+}
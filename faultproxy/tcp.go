@@ -0,0 +1,98 @@
+package faultproxy
+
+import (
+	"io"
+	"net"
+)
+
+func (proxy *Proxy) listenTCP(link Link) error {
+	listener, err := net.Listen("tcp", link.Listen)
+	if err != nil {
+		return err
+	}
+
+	proxy.lock.Lock()
+	proxy.addrs[link.Name] = listener.Addr()
+	proxy.lock.Unlock()
+	if !proxy.track(listener) {
+		return nil
+	}
+
+	proxy.wg.Add(1)
+	go func() {
+		defer proxy.wg.Done()
+
+		for {
+			client, err := listener.Accept()
+			if err != nil {
+				// closed by Stop
+				return
+			}
+
+			proxy.wg.Add(1)
+			go func() {
+				defer proxy.wg.Done()
+				proxy.forwardTCP(link, client)
+			}()
+		}
+	}()
+
+	return nil
+}
+
+// Forwards one connection to the upstream, unless the link is partitioned
+func (proxy *Proxy) forwardTCP(link Link, client net.Conn) {
+	if !proxy.track(client) {
+		return
+	}
+	defer proxy.untrack(client)
+
+	if proxy.active(link.Name).Partition {
+		proxy.count(link.Name, func(stats *Stats) { stats.Dropped++ })
+		return
+	}
+
+	upstream, err := net.Dial("tcp", link.Upstream)
+	if err != nil {
+		return
+	}
+	if !proxy.track(upstream) {
+		return
+	}
+	defer proxy.untrack(upstream)
+
+	finished := make(chan struct{})
+	go func() {
+		proxy.pipe(link.Name, client, upstream)
+		close(finished)
+	}()
+	proxy.pipe(link.Name, upstream, client)
+	<-finished
+}
+
+// Delivers what is read from src to dst until src is closed
+func (proxy *Proxy) pipe(name string, src net.Conn, dst net.Conn) {
+	out := &writer{write: func(message []byte) error {
+		_, err := dst.Write(message)
+		return err
+	}}
+
+	buffer := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(buffer)
+		if n > 0 {
+			proxy.deliver(name, buffer[:n], out)
+		}
+		if err == io.EOF {
+			// let the other side finish its answer
+			if tcp, ok := dst.(*net.TCPConn); ok {
+				tcp.CloseWrite()
+				return
+			}
+		}
+		if err != nil {
+			dst.Close()
+			return
+		}
+	}
+}
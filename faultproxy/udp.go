@@ -0,0 +1,94 @@
+package faultproxy
+
+import (
+	"net"
+)
+
+func (proxy *Proxy) listenUDP(link Link) error {
+	upstream, err := net.ResolveUDPAddr("udp", link.Upstream)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.ListenPacket("udp", link.Listen)
+	if err != nil {
+		return err
+	}
+
+	proxy.lock.Lock()
+	proxy.addrs[link.Name] = listener.LocalAddr()
+	proxy.lock.Unlock()
+	if !proxy.track(listener) {
+		return nil
+	}
+
+	proxy.wg.Add(1)
+	go func() {
+		defer proxy.wg.Done()
+
+		sessions := make(map[string]*writer)
+		buffer := make([]byte, 64*1024)
+		for {
+			n, client, err := listener.ReadFrom(buffer)
+			if err != nil {
+				// closed by Stop
+				return
+			}
+
+			out, ok := sessions[client.String()]
+			if !ok {
+				out, ok = proxy.openUDPSession(link, listener, client, upstream)
+				if !ok {
+					continue
+				}
+				sessions[client.String()] = out
+			}
+
+			proxy.deliver(link.Name, buffer[:n], out)
+		}
+	}()
+
+	return nil
+}
+
+// Opens a socket to the upstream for a client, so that the answers of the upstream
+// find their way back to it. Returns the writer of the messages to the upstream.
+func (proxy *Proxy) openUDPSession(link Link, listener net.PacketConn, client net.Addr,
+	upstream *net.UDPAddr) (*writer, bool) {
+	conn, err := net.DialUDP("udp", nil, upstream)
+	if err != nil {
+		return nil, false
+	}
+	if !proxy.track(conn) {
+		return nil, false
+	}
+
+	back := &writer{write: func(message []byte) error {
+		_, err := listener.WriteTo(message, client)
+		return err
+	}}
+
+	proxy.wg.Add(1)
+	go func() {
+		defer proxy.wg.Done()
+
+		buffer := make([]byte, 64*1024)
+		for {
+			n, err := conn.Read(buffer)
+			if err != nil {
+				if !proxy.wait(0) {
+					return
+				}
+				// the upstream refused a message, e.g. because it does not listen yet
+				continue
+			}
+
+			proxy.deliver(link.Name, buffer[:n], back)
+		}
+	}()
+
+	return &writer{write: func(message []byte) error {
+		_, err := conn.Write(message)
+		return err
+	}}, true
+}
@@ -20,9 +20,12 @@ func MutatorCase(pkg *types.Package, info *types.Info, node ast.Node) []mutator.
 	}
 
 	old := n.Body
+	pos, end := mutator.StatementsSpan(old, n)
 
 	return []mutator.Mutation{
 		mutator.Mutation{
+			Pos: pos,
+			End: end,
 			Change: func() {
 				n.Body = []ast.Stmt{
 					astutil.CreateNoopOfStatements(pkg, info, n.Body),
@@ -28,6 +28,8 @@ func MutatorElse(pkg *types.Package, info *types.Info, node ast.Node) []mutator.
 
 	return []mutator.Mutation{
 		mutator.Mutation{
+			Pos: old.Pos(),
+			End: old.End(),
 			Change: func() {
 				n.Else = astutil.CreateNoopOfStatement(pkg, info, old)
 			},
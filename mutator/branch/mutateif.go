@@ -20,9 +20,12 @@ func MutatorIf(pkg *types.Package, info *types.Info, node ast.Node) []mutator.Mu
 	}
 
 	old := n.Body.List
+	pos, end := mutator.StatementsSpan(old, n.Body)
 
 	return []mutator.Mutation{
 		mutator.Mutation{
+			Pos: pos,
+			End: end,
 			Change: func() {
 				n.Body.List = []ast.Stmt{
 					astutil.CreateNoopOfStatement(pkg, info, n.Body),
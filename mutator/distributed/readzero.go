@@ -19,6 +19,8 @@ func MutatorReadZero(pkg *types.Package, info *types.Info, node ast.Node) []muta
 			newAssign := astutil.CreateReadZeroAssignment(block, info)
 			if newAssign != nil {
 				mutation := createMutant(blocks, blocks.List, newAssign, i+1)
+				// the assignment goes right after the statement
+				mutation.Pos, mutation.End = block.Pos(), block.End()
 				mutationList = append(mutationList, mutation)
 			}
 		}
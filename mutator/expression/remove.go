@@ -36,6 +36,8 @@ func MutatorRemoveTerm(pkg *types.Package, info *types.Info, node ast.Node) []mu
 
 	return []mutator.Mutation{
 		mutator.Mutation{
+			Pos: x.Pos(),
+			End: x.End(),
 			Change: func() {
 				n.X = r
 			},
@@ -44,6 +46,8 @@ func MutatorRemoveTerm(pkg *types.Package, info *types.Info, node ast.Node) []mu
 			},
 		},
 		mutator.Mutation{
+			Pos: y.Pos(),
+			End: y.End(),
 			Change: func() {
 				n.Y = r
 			},
@@ -1,9 +1,35 @@
 package mutator
 
+import (
+	"go/ast"
+	"go/token"
+)
+
 // Mutation defines the behavior of one mutation
 type Mutation struct {
 	// Change is called before executing the exec command.
 	Change func()
 	// Reset is called after executing the exec command.
 	Reset func()
+	// Pos and End enclose the code which is changed. If Pos is not set, it is the node the mutator was called on.
+	Pos token.Pos
+	End token.Pos
+}
+
+// Span returns the positions of the code which the mutation changes. The node is the one the mutator was called on.
+func (m Mutation) Span(node ast.Node) (token.Pos, token.Pos) {
+	if m.Pos == token.NoPos {
+		return node.Pos(), node.End()
+	}
+
+	return m.Pos, m.End
+}
+
+// StatementsSpan returns the positions enclosing the statements, or the ones of the fallback node if there are none.
+func StatementsSpan(statements []ast.Stmt, fallback ast.Node) (token.Pos, token.Pos) {
+	if len(statements) == 0 {
+		return fallback.Pos(), fallback.End()
+	}
+
+	return statements[0].Pos(), statements[len(statements)-1].End()
 }
@@ -46,6 +46,8 @@ func MutatorRemoveStatement(pkg *types.Package, info *types.Info, node ast.Node)
 			old := l[li]
 
 			mutations = append(mutations, mutator.Mutation{
+				Pos: old.Pos(),
+				End: old.End(),
 				Change: func() {
 					l[li] = astutil.CreateNoopOfStatement(pkg, info, old)
 				},
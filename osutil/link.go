@@ -0,0 +1,15 @@
+package osutil
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrReflinkUnsupported is returned by ReflinkFile where reflinks are not available
+var ErrReflinkUnsupported = errors.New("reflinks are not supported")
+
+// LinkFile makes dst a hard link of src, so both share their content
+// Writing to one of them in place changes the other as well.
+func LinkFile(src string, dst string) error {
+	return os.Link(src, dst)
+}
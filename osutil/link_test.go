@@ -0,0 +1,53 @@
+package osutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osutil")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	assert.Nil(t, ioutil.WriteFile(src, []byte("shared"), 0644))
+
+	assert.Nil(t, LinkFile(src, dst))
+
+	s, _ := os.Stat(src)
+	d, _ := os.Stat(dst)
+	assert.True(t, os.SameFile(s, d))
+}
+
+func TestReflinkFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osutil")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	assert.Nil(t, ioutil.WriteFile(src, []byte("shared"), 0644))
+
+	err = ReflinkFile(src, dst)
+	if err == ErrReflinkUnsupported {
+		_, statErr := os.Stat(dst)
+		assert.True(t, os.IsNotExist(statErr))
+		t.Skip("file system of the temporary directory has no reflinks")
+	}
+	assert.Nil(t, err)
+
+	data, err := ioutil.ReadFile(dst)
+	assert.Nil(t, err)
+	assert.Equal(t, "shared", string(data))
+
+	// the clone is written on its own
+	assert.Nil(t, ioutil.WriteFile(dst, []byte("changed"), 0644))
+	data, _ = ioutil.ReadFile(src)
+	assert.Equal(t, "shared", string(data))
+}
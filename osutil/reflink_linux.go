@@ -0,0 +1,47 @@
+package osutil
+
+import (
+	"os"
+	"syscall"
+)
+
+// ioctl which shares the extents of one file with another, see ioctl_ficlone(2)
+const ficlone = 0x40049409
+
+// ReflinkFile makes dst a copy-on-write clone of src, so both share their content
+// until one of them is written. Fails on file systems without reflinks, e.g. ext4.
+func ReflinkFile(src string, dst string) (err error) {
+	s, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	i, err := s.Stat()
+	if err != nil {
+		return err
+	}
+
+	d, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, i.Mode())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := d.Close()
+		if err == nil {
+			err = e
+		}
+	}()
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, d.Fd(), ficlone, s.Fd())
+	if errno != 0 {
+		d.Close()
+		os.Remove(dst)
+		if errno == syscall.EOPNOTSUPP || errno == syscall.ENOTTY || errno == syscall.EXDEV || errno == syscall.EINVAL {
+			return ErrReflinkUnsupported
+		}
+		return errno
+	}
+
+	return nil
+}
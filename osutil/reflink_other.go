@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package osutil
+
+// ReflinkFile is only implemented on Linux
+func ReflinkFile(src string, dst string) error {
+	return ErrReflinkUnsupported
+}
@@ -3,6 +3,7 @@ package mutesting
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
 	"strings"
 
@@ -45,11 +46,18 @@ func (w *countWalk) Visit(node ast.Node) ast.Visitor {
 // MutateWalk mutates the given node with the given mutator returning a channel to control the mutation steps.
 // It traverses the AST of the given node and calls the method Check of the given mutator to verify that a node can be mutated by the mutator. If a node can be mutated the method Mutate of the given mutator is executed with the node and the control channel. After completion of the traversal the control channel is closed.
 func MutateWalk(pkg *types.Package, info *types.Info, node ast.Node, m mutator.Mutator) chan bool {
+	return MutateWalkFiltered(pkg, info, node, m, nil)
+}
+
+// MutateWalkFiltered works like MutateWalk but only applies the mutations for which the given filter returns true.
+// The filter gets the positions of the code a mutation changes, see mutator.Mutation.Span, and is called right before the mutation is applied. A nil filter accepts every mutation.
+func MutateWalkFiltered(pkg *types.Package, info *types.Info, node ast.Node, m mutator.Mutator, filter func(pos token.Pos, end token.Pos) bool) chan bool {
 	w := &mutateWalk{
 		changed: make(chan bool),
 		mutator: m,
 		pkg:     pkg,
 		info:    info,
+		filter:  filter,
 	}
 
 	go func() {
@@ -66,6 +74,7 @@ type mutateWalk struct {
 	mutator mutator.Mutator
 	pkg     *types.Package
 	info    *types.Info
+	filter  func(pos token.Pos, end token.Pos) bool
 }
 
 // Visit implements the Visit method of the ast.Visitor interface
@@ -75,6 +84,10 @@ func (w *mutateWalk) Visit(node ast.Node) ast.Visitor {
 	}
 
 	for _, m := range w.mutator(w.pkg, w.info, node) {
+		if w.filter != nil && !w.filter(m.Span(node)) {
+			continue
+		}
+
 		m.Change()
 		w.changed <- true
 		<-w.changed